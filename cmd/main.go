@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/fir1/port/config"
@@ -17,17 +18,35 @@ import (
 )
 
 func main() {
-	err := fx.New(
+	var restServer *http_rest.Service
+	app := fx.New(
 		fx.Options(
 			config.FxProvide,
 			port.FxProvide,
 			http_rest.FxProvide,
 		),
-		fx.Invoke(run),
-	).Err()
+		fx.Populate(&restServer),
+	)
+	err := app.Err()
 	if err != nil {
 		log.Panic(err)
 	}
+
+	// start and stop the lifecycle hooks, e.g. the persistent repository must be closed on shutdown
+	err = app.Start(context.Background())
+	if err != nil {
+		log.Panic(err)
+	}
+
+	runErr := run(restServer)
+
+	err = app.Stop(context.Background())
+	if err != nil {
+		log.Print(err)
+	}
+	if runErr != nil {
+		log.Panic(runErr)
+	}
 }
 
 func run(restServer *http_rest.Service) error {
@@ -1,10 +1,21 @@
 package config
 
 import (
+	"time"
+
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 )
 
+const (
+	// RepositoryDriverMemory keeps the ports in memory only, they are lost on restart.
+	RepositoryDriverMemory = "memory"
+	// RepositoryDriverFile keeps the ports in memory and persists them in DataDir.
+	RepositoryDriverFile = "file"
+	// RepositoryDriverSQL keeps the ports in the SQL database configured with SQLDriver and SQLDataSource.
+	RepositoryDriverSQL = "sql"
+)
+
 func NewParsedConfig() (Config, error) {
 	_ = godotenv.Load() // The Original .env
 	cnf := Config{}
@@ -18,4 +29,39 @@ type Config struct {
 	Port                 int    `envconfig:"PORT" default:"8080"`
 	LoadBalancerHostPort int    `envconfig:"LOAD_BALANCER_HOST_PORT" default:"8080"`
 	DataDir              string `envconfig:"DATA_DIR" default:"data"`
+	RepositoryDriver     string `envconfig:"REPOSITORY_DRIVER" default:"memory"`
+	SQLDriver            string `envconfig:"SQL_DRIVER" default:"sqlite"`
+	// SQLDataSource is the driver specific data source name, by default SQLite database is created in DataDir.
+	SQLDataSource string `envconfig:"SQL_DATA_SOURCE"`
+	// ImportConcurrency is the number of the import jobs which run at the same time, ImportQueueSize more jobs
+	// can wait for their turn.
+	ImportConcurrency int `envconfig:"IMPORT_CONCURRENCY" default:"2"`
+	ImportQueueSize   int `envconfig:"IMPORT_QUEUE_SIZE" default:"10"`
+	// ImportJobTTL is how long a finished import job can still be looked up.
+	ImportJobTTL time.Duration `envconfig:"IMPORT_JOB_TTL" default:"1h"`
+	// ImportCSVColumns maps the headers of the imported CSV and TSV files to the fields of the ports,
+	// e.g. "Port Name:name,LOCODE:unlocode". The headers named like the fields do not have to be mapped.
+	ImportCSVColumns map[string]string `envconfig:"IMPORT_CSV_COLUMNS"`
+	// ImportCSVListSeparator separates the values of the alias, regions and unlocs columns.
+	ImportCSVListSeparator string `envconfig:"IMPORT_CSV_LIST_SEPARATOR" default:";"`
+	// ImportMaxBodySize is the maximum size of the body of PUT /ports/bulk as it is sent, before it is decompressed.
+	// The size is not limited when it is 0.
+	ImportMaxBodySize int64 `envconfig:"IMPORT_MAX_BODY_SIZE" default:"1073741824"`
+	// ImportDownloadAttempts is the number of the attempts of every request of a remote import, the delay between
+	// them doubles from ImportDownloadRetryDelay.
+	ImportDownloadAttempts   uint          `envconfig:"IMPORT_DOWNLOAD_ATTEMPTS" default:"5"`
+	ImportDownloadRetryDelay time.Duration `envconfig:"IMPORT_DOWNLOAD_RETRY_DELAY" default:"1s"`
+	// ImportDownloadTimeout is how long the server of a remote import may keep a request waiting for the response
+	// or for the next bytes of the body before the request is retried.
+	ImportDownloadTimeout time.Duration `envconfig:"IMPORT_DOWNLOAD_TIMEOUT" default:"30s"`
+	// ImportWatch imports the port files dropped into ImportWatchDir in the background, a relative ImportWatchDir
+	// is in DataDir. It is scanned every ImportWatchInterval and a file is imported once it has not changed
+	// for ImportWatchDebounce, in ImportWatchMode.
+	ImportWatch         bool          `envconfig:"IMPORT_WATCH" default:"false"`
+	ImportWatchDir      string        `envconfig:"IMPORT_WATCH_DIR" default:"inbox"`
+	ImportWatchInterval time.Duration `envconfig:"IMPORT_WATCH_INTERVAL" default:"2s"`
+	ImportWatchDebounce time.Duration `envconfig:"IMPORT_WATCH_DEBOUNCE" default:"10s"`
+	ImportWatchMode     string        `envconfig:"IMPORT_WATCH_MODE" default:"strict"`
+	// ShutdownTimeout is how long the server waits for the running requests when it shuts down.
+	ShutdownTimeout time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"15s"`
 }
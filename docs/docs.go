@@ -44,9 +44,188 @@ const docTemplate = `{
                 }
             }
         },
+        "/imports": {
+            "post": {
+                "description": "The file is stored and imported by a background job, the response is returned at once with the job ID.\nThe file is JSON, NDJSON, CSV or TSV, told apart like by ` + "`" + `POST /ports/from-file` + "`" + `.\nInstead of an upload, a JSON body ` + "`" + `{\"source_url\": \"https://...\", \"checksum\": \"sha256:...\"}` + "`" + ` imports\nthe file of an HTTP or an HTTPS URL. The file is streamed to the import as it is downloaded, a dropped\ndownload is resumed and the failed requests are retried. The optional checksum is ` + "`" + `\u003calgorithm\u003e:\u003chex digest\u003e` + "`" + `\nwith md5, sha1, sha256 or sha512, the import of a file which does not match it is rolled back.\nThe job can be followed with ` + "`" + `GET /imports/{id}` + "`" + ` and cancelled with ` + "`" + `DELETE /imports/{id}` + "`" + `.",
+                "consumes": [
+                    "multipart/form-data",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imports"
+                ],
+                "summary": "Starts an import of a ports file in the background",
+                "operationId": "create-import",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "File, unless the source_url is in a JSON body",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "enum": [
+                            "strict",
+                            "lenient"
+                        ],
+                        "type": "string",
+                        "description": "Import mode",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only report the diff",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Delete the ports missing from the file",
+                        "name": "sync",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/service.ImportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imports/{id}": {
+            "get": {
+                "description": "Retrieves the state, the progress and, when the job has finished, the report of the import.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imports"
+                ],
+                "summary": "Retrieves the state of an import job",
+                "operationId": "get-import",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Import job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.ImportJob"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "A queued job never starts, the import of a running job is rolled back.\nThe job is returned as it is when it is cancelled, it may still be running for a moment.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imports"
+                ],
+                "summary": "Cancels an import job",
+                "operationId": "cancel-import",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Import job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/service.ImportJob"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imports/{id}/events": {
+            "get": {
+                "description": "Streams Server-Sent Events until the job has finished. A ` + "`" + `progress` + "`" + ` event with the counters of the job\nis sent at once and then every half a second, an ` + "`" + `error` + "`" + ` event is sent with every record skipped\nby the import as it happens and a ` + "`" + `done` + "`" + ` event with the finished job ends the stream. The stream\nalso ends without the ` + "`" + `done` + "`" + ` event when the server shuts down.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Imports"
+                ],
+                "summary": "Streams the progress of an import job",
+                "operationId": "import-events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Import job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/ports": {
             "get": {
-                "description": "It will return all the available ports from the DB. We will use API caching for this purpose\nso we don't have to get all data over again from DB, which is useful in real world applications\nwhere we are connected to the real database such as PostgresSQL it saves a lot of latency.",
+                "description": "It will return a page of the available ports from the DB. We will use API caching for this purpose\nso we don't have to get all data over again from DB, which is useful in real world applications\nwhere we are connected to the real database such as PostgresSQL it saves a lot of latency.\nin case if the list empty, it means that you should call API endpoint ` + "`" + `POST /ports` + "`" + ` it\nwill parse ` + "`" + `ports.json` + "`" + ` file and saves into the DB, then you can make a call to ` + "`" + `GET /ports` + "`" + `\nto get all the available ports from the DB.\nThe ports are returned in a stable order, pass ` + "`" + `next_cursor` + "`" + ` of the response as ` + "`" + `cursor` + "`" + `\nto get the next page, there are no more ports when ` + "`" + `next_cursor` + "`" + ` is empty.\nThe ports can be filtered, all the filters are case-insensitive.",
                 "consumes": [
                     "application/json"
                 ],
@@ -56,14 +235,84 @@ const docTemplate = `{
                 "tags": [
                     "Ports"
                 ],
-                "summary": "It will return all the available ports from the DB",
+                "summary": "It will return a page of the available ports from the DB",
                 "operationId": "list-ports",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of ports in the page, 100 by default and 1000 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page, next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of ports to skip, can not be used with the cursor",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by unlocode (default), code, name or country, prefix with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Germany",
+                        "description": "Country of the port",
+                        "name": "country",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Province of the port",
+                        "name": "province",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "City of the port",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Asia/Dubai",
+                        "description": "Timezone of the port",
+                        "name": "timezone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "One of the UN/LOCODEs of the port",
+                        "name": "unloc",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Prefix of the port name",
+                        "name": "name",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
-                    "201": {
-                        "description": "Created"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Page"
+                        }
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
                     },
                     "500": {
                         "description": "Internal Server Error"
@@ -71,7 +320,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "It will create ports and save into the DB by default ports.json file will be used",
+                "description": "It will create ports and save into the DB by default ports.json file will be used.\nIn the strict mode (default) the import stops at the first invalid record, in the lenient mode\nthe invalid records are skipped and listed in the report.\nA sync deletes the stored ports missing from the file.\nThe import is written in a single transaction, a failed import leaves the stored ports intact.",
                 "consumes": [
                     "application/json"
                 ],
@@ -83,12 +332,264 @@ const docTemplate = `{
                 ],
                 "summary": "It will create ports and save into the DB by default ports.json file will be used",
                 "operationId": "save-ports",
+                "parameters": [
+                    {
+                        "enum": [
+                            "strict",
+                            "lenient"
+                        ],
+                        "type": "string",
+                        "description": "Import mode",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only report the diff",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Delete the ports missing from the file, all or nothing",
+                        "name": "sync",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
+                    "200": {
+                        "description": "Dry run",
+                        "schema": {
+                            "$ref": "#/definitions/service.ImportReport"
+                        }
+                    },
                     "201": {
-                        "description": "Created"
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/service.ImportReport"
+                        }
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/http.importErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/ports/autocomplete": {
+            "get": {
+                "description": "It will return the ports whose name, city, alias or UN/LOCODE starts with the prefix. Exact matches\ncome first, then UN/LOCODE, name, city and alias matches. Any word of the name can be typed, e.g. \"dhab\"\nsuggests Abu Dhabi.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ports"
+                ],
+                "summary": "It will suggest the ports for the typed prefix",
+                "operationId": "autocomplete-ports",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "abu d",
+                        "description": "Typed prefix",
+                        "name": "prefix",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of ports, 10 by default and 100 at most",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Suggestion"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/ports/bulk": {
+            "put": {
+                "description": "The body is decoded as it is received, without multipart encoding or temporary files, e.g.\n` + "`" + `curl -T ports.json.gz -H \"Content-Encoding: gzip\" http://localhost:8080/ports/bulk` + "`" + `.\nThe format is told by the Content-Type: ` + "`" + `application/json` + "`" + ` (default), ` + "`" + `application/x-ndjson` + "`" + `, ` + "`" + `text/csv` + "`" + `\nor ` + "`" + `text/tab-separated-values` + "`" + `. A gzip or a zstd body is decompressed by its Content-Encoding.\nThe body may be at most ` + "`" + `IMPORT_MAX_BODY_SIZE` + "`" + ` bytes as it is sent. The request is checked before\nthe body is read, so a client sending ` + "`" + `Expect: 100-continue` + "`" + ` does not send a body which would be refused.\nThe modes, the dry run and the sync are the ones of ` + "`" + `POST /ports/from-file` + "`" + `.",
+                "consumes": [
+                    "application/json",
+                    "application/x-ndjson",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ports"
+                ],
+                "summary": "Imports the ports streamed in the request body",
+                "operationId": "put-ports-bulk",
+                "parameters": [
+                    {
+                        "description": "Ports file",
+                        "name": "ports",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "strict",
+                            "lenient"
+                        ],
+                        "type": "string",
+                        "description": "Import mode",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only report the diff",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Delete the ports missing from the body, all or nothing",
+                        "name": "sync",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "identity",
+                            "gzip",
+                            "zstd"
+                        ],
+                        "type": "string",
+                        "description": "Compression of the body",
+                        "name": "Content-Encoding",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dry run",
+                        "schema": {
+                            "$ref": "#/definitions/service.ImportReport"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/service.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/http.importErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/http.importErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/ports/distance-matrix": {
+            "get": {
+                "description": "It will return an N×N table of the great-circle distances in kilometres and nautical miles,\nthe row i and the column j hold the distance from the i-th port to the j-th port.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ports"
+                ],
+                "summary": "It will return the distances between every pair of the ports",
+                "operationId": "distance-matrix",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "AEAJM,AEDXB,AEJEA",
+                        "description": "Comma separated UN/LOCODEs, 100 at most",
+                        "name": "unlocodes",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.DistanceMatrix"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
                     },
                     "500": {
                         "description": "Internal Server Error"
@@ -98,7 +599,7 @@ const docTemplate = `{
         },
         "/ports/from-file": {
             "post": {
-                "description": "You are able to provide json file the service will parse and save into the DB",
+                "description": "You are able to provide json file the service will parse and save into the DB.\nIn the strict mode (default) the import stops at the first invalid record, in the lenient mode\nthe invalid records are skipped and listed in the report.\nA dry run compares the file with the stored ports and responds with the diff without writing anything.\nA sync deletes the stored ports missing from the file.\nThe file is a JSON object of the ports keyed by their UN/LOCODE, NDJSON with a ` + "`" + `{\"code\": ..., \"port\": {...}}` + "`" + `\nobject on every line, CSV or TSV. The format is told by the content type of the file part, or by the extension of its name\n(` + "`" + `.json` + "`" + `, ` + "`" + `.ndjson` + "`" + `, ` + "`" + `.jsonl` + "`" + `, ` + "`" + `.csv` + "`" + `, ` + "`" + `.tsv` + "`" + `). Gzip and zstd files and zip archives are decompressed as they are read.\nThe import is written in a single transaction, a failed import leaves the stored ports intact.",
                 "consumes": [
                     "multipart/form-data"
                 ],
@@ -117,20 +618,1109 @@ const docTemplate = `{
                         "name": "file",
                         "in": "formData",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "strict",
+                            "lenient"
+                        ],
+                        "type": "string",
+                        "description": "Import mode",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only report the diff",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Delete the ports missing from the file, all or nothing",
+                        "name": "sync",
+                        "in": "query"
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "Dry run",
+                        "schema": {
+                            "$ref": "#/definitions/service.ImportReport"
+                        }
+                    },
                     "201": {
-                        "description": "Created"
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/service.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/http.importErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/ports/nearby": {
+            "get": {
+                "description": "It will return the ports within the radius around the location sorted by the great-circle distance,\nthe closest port first. The distance in kilometres is included in each port.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ports"
+                ],
+                "summary": "It will return the ports around a location",
+                "operationId": "nearby-ports",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "example": 25.27,
+                        "description": "Latitude of the location",
+                        "name": "lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "example": 55.3,
+                        "description": "Longitude of the location",
+                        "name": "lon",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Search radius in kilometres, 100 by default",
+                        "name": "radius_km",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of ports, 10 by default and 1000 at most",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.NearbyPort"
+                            }
+                        }
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
                     },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             }
+        },
+        "/ports/search": {
+            "get": {
+                "description": "It will return the ports whose name, city, alias or province match the query, the best match first.\nThe search ignores the case and the diacritics and tolerates typos, e.g. \"abu dabi\" finds Abu Dhabi.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ports"
+                ],
+                "summary": "It will search the ports by their name, city, alias and province",
+                "operationId": "search-ports",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "Foochow",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of ports, 10 by default and 100 at most",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.SearchResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/ports/{unlocode}": {
+            "get": {
+                "description": "It will return a single port by its UN/LOCODE. Each port is cached on its own\nso the client can resolve one port without fetching the whole list.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ports"
+                ],
+                "summary": "It will return a single port by its UN/LOCODE",
+                "operationId": "get-port",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "AEAJM",
+                        "description": "UN/LOCODE of the port",
+                        "name": "unlocode",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Port"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "put": {
+                "description": "It will create or fully replace the port stored under the UN/LOCODE.\nOnly the cache of this port and the cached lists are invalidated.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ports"
+                ],
+                "summary": "It will create or fully replace a port",
+                "operationId": "put-port",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "AEAJM",
+                        "description": "UN/LOCODE of the port",
+                        "name": "unlocode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Port",
+                        "name": "port",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Port"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Port"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Port"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "It will delete the port stored under the UN/LOCODE.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ports"
+                ],
+                "summary": "It will delete a port",
+                "operationId": "delete-port",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "AEAJM",
+                        "description": "UN/LOCODE of the port",
+                        "name": "unlocode",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "patch": {
+                "description": "It will apply JSON Merge Patch (RFC 7396) document to the port stored under the UN/LOCODE.\nFields set to null are removed, the rest of the fields are replaced.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ports"
+                ],
+                "summary": "It will partially update a port",
+                "operationId": "patch-port",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "AEAJM",
+                        "description": "UN/LOCODE of the port",
+                        "name": "unlocode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "JSON Merge Patch document",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Port"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/ports/{unlocode}/distance/{to}": {
+            "get": {
+                "description": "It will return the great-circle distance between two ports in kilometres and nautical miles\nand the initial bearing from the first port to the second one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ports"
+                ],
+                "summary": "It will return the distance between two ports",
+                "operationId": "port-distance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "AEAJM",
+                        "description": "UN/LOCODE of the port to measure from",
+                        "name": "unlocode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "AEDXB",
+                        "description": "UN/LOCODE of the port to measure to",
+                        "name": "to",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PortDistance"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "http.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "violations": {
+                    "description": "Violations are the violations of the port when it does not pass the validation.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/validation.Violation"
+                    }
+                }
+            }
+        },
+        "http.importErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "report": {
+                    "$ref": "#/definitions/service.ImportReport"
+                },
+                "violations": {
+                    "description": "Violations are the violations of the port when it does not pass the validation.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/validation.Violation"
+                    }
+                }
+            }
+        },
+        "model.DistanceMatrix": {
+            "type": "object",
+            "properties": {
+                "distances_km": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "number"
+                        }
+                    }
+                },
+                "distances_nm": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "number"
+                        }
+                    }
+                },
+                "unlocodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.NearbyPort": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "city": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "coordinates": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "country": {
+                    "type": "string"
+                },
+                "distance_km": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "province": {
+                    "type": "string"
+                },
+                "regions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Region"
+                    }
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "unlocode": {
+                    "type": "string"
+                },
+                "unlocs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Page": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PortRecord"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Port": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "city": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "coordinates": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "country": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "province": {
+                    "type": "string"
+                },
+                "regions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Region"
+                    }
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "unlocs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.PortDistance": {
+            "type": "object",
+            "properties": {
+                "distance_km": {
+                    "type": "number"
+                },
+                "distance_nm": {
+                    "type": "number"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "initial_bearing": {
+                    "description": "InitialBearing is the bearing at the From port in degrees clockwise from the true north.",
+                    "type": "number"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PortRecord": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "city": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "coordinates": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "country": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "province": {
+                    "type": "string"
+                },
+                "regions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Region"
+                    }
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "unlocode": {
+                    "type": "string"
+                },
+                "unlocs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Region": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.SearchResult": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "city": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "coordinates": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "country": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "province": {
+                    "type": "string"
+                },
+                "regions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Region"
+                    }
+                },
+                "score": {
+                    "type": "number"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "unlocode": {
+                    "type": "string"
+                },
+                "unlocs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Suggestion": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "city": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "coordinates": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "country": {
+                    "type": "string"
+                },
+                "exact": {
+                    "description": "Exact is true when the whole text equals the prefix.",
+                    "type": "boolean"
+                },
+                "match": {
+                    "description": "Match is the text of the port which starts with the prefix.",
+                    "type": "string"
+                },
+                "match_type": {
+                    "description": "MatchType is one of the MatchType constants.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "province": {
+                    "type": "string"
+                },
+                "regions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Region"
+                    }
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "unlocode": {
+                    "type": "string"
+                },
+                "unlocs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.DiffAction": {
+            "type": "string",
+            "enum": [
+                "added",
+                "changed",
+                "removed"
+            ],
+            "x-enum-varnames": [
+                "DiffActionAdded",
+                "DiffActionChanged",
+                "DiffActionRemoved"
+            ]
+        },
+        "service.FieldChange": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "new": {},
+                "old": {},
+                "type": {
+                    "$ref": "#/definitions/service.FieldChangeType"
+                }
+            }
+        },
+        "service.FieldChangeType": {
+            "type": "string",
+            "enum": [
+                "added",
+                "removed",
+                "changed"
+            ],
+            "x-enum-varnames": [
+                "FieldAdded",
+                "FieldRemoved",
+                "FieldChanged"
+            ]
+        },
+        "service.ImportFormat": {
+            "type": "string",
+            "enum": [
+                "json",
+                "ndjson",
+                "csv",
+                "tsv"
+            ],
+            "x-enum-varnames": [
+                "ImportFormatJSON",
+                "ImportFormatNDJSON",
+                "ImportFormatCSV",
+                "ImportFormatTSV"
+            ]
+        },
+        "service.ImportJob": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Error is the reason why the job has failed.",
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "options": {
+                    "$ref": "#/definitions/service.ImportJobOptions"
+                },
+                "progress": {
+                    "$ref": "#/definitions/service.ImportJobProgress"
+                },
+                "report": {
+                    "description": "Report is the report of the finished import, it is missing when the import could not start.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.ImportReport"
+                        }
+                    ]
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "state": {
+                    "$ref": "#/definitions/service.ImportJobState"
+                }
+            }
+        },
+        "service.ImportJobOptions": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "format": {
+                    "$ref": "#/definitions/service.ImportFormat"
+                },
+                "mode": {
+                    "$ref": "#/definitions/service.ImportMode"
+                },
+                "sync": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "service.ImportJobProgress": {
+            "type": "object",
+            "properties": {
+                "bytes_read": {
+                    "type": "integer"
+                },
+                "bytes_total": {
+                    "description": "BytesTotal is the size of the file, it is missing when the size is not known.",
+                    "type": "integer"
+                },
+                "records_decoded": {
+                    "type": "integer"
+                },
+                "records_processed": {
+                    "description": "RecordsProcessed is the number of the records which have been written or skipped.",
+                    "type": "integer"
+                },
+                "records_skipped": {
+                    "type": "integer"
+                },
+                "records_written": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.ImportJobState": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "running",
+                "succeeded",
+                "failed",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "ImportJobQueued",
+                "ImportJobRunning",
+                "ImportJobSucceeded",
+                "ImportJobFailed",
+                "ImportJobCancelled"
+            ]
+        },
+        "service.ImportMode": {
+            "type": "string",
+            "enum": [
+                "strict",
+                "lenient"
+            ],
+            "x-enum-varnames": [
+                "ImportModeStrict",
+                "ImportModeLenient"
+            ]
+        },
+        "service.ImportReport": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "description": "Created, Updated and Unchanged count the ports saved by the import, Unchanged ports are not written.\nIn a dry run they count the ports which would be saved.",
+                    "type": "integer"
+                },
+                "deleted": {
+                    "description": "Deleted counts the ports deleted by a sync.",
+                    "type": "integer"
+                },
+                "diff": {
+                    "description": "Diff lists the added and the changed ports of a dry run in the order of the file, followed by the ports\nremoved by a sync.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.PortDiff"
+                    }
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.RecordError"
+                    }
+                },
+                "errors_truncated": {
+                    "description": "ErrorsTruncated is true when there were more than MaxReportErrors errors.",
+                    "type": "boolean"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "mode": {
+                    "$ref": "#/definitions/service.ImportMode"
+                },
+                "rolled_back": {
+                    "description": "RolledBack is true when the import has been stopped by an error, the counts tell what had been done\nbefore the writes were rolled back.",
+                    "type": "boolean"
+                },
+                "skipped": {
+                    "description": "Skipped counts the records which were not imported because of an error.",
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "sync": {
+                    "type": "boolean"
+                },
+                "unchanged": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.PortDiff": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/service.DiffAction"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.FieldChange"
+                    }
+                },
+                "file": {
+                    "description": "File is the archive member of the record, it is empty unless an archive has been imported.",
+                    "type": "string"
+                },
+                "record": {
+                    "description": "Record is the position of the port in the file, starting from 1. It is 0 for the removed ports.",
+                    "type": "integer"
+                },
+                "unlocode": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.RecordError": {
+            "type": "object",
+            "properties": {
+                "file": {
+                    "description": "File is the archive member of the record, it is empty unless an archive has been imported.",
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "record": {
+                    "description": "Record is the position of the port in the file, starting from 1.",
+                    "type": "integer"
+                },
+                "unlocode": {
+                    "type": "string"
+                },
+                "violations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/validation.Violation"
+                    }
+                }
+            }
+        },
+        "validation.Violation": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
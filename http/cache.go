@@ -0,0 +1,90 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+)
+
+// listGenerationCacheKey holds the current generation of the cached port lists. The generation is a part of every
+// list cache key, so all the lists can be invalidated at once by starting a new generation instead of Reset().
+const listGenerationCacheKey = "ports:list:generation"
+
+// portCacheKey returns the cache key for a single port, so a port can be cached and
+// invalidated on its own without touching the rest of the cache.
+func portCacheKey(unlocode string) string {
+	return "port:" + unlocode
+}
+
+// listCacheKey returns the cache key for the list of ports requested with the query.
+func (s *Service) listCacheKey(query string) (string, error) {
+	generation, err := s.cacheClient.Get(listGenerationCacheKey)
+	switch {
+	case err == nil:
+	case errors.Is(err, bigcache.ErrEntryNotFound):
+		// the generation could have been evicted, entries written under the old one must not be used anymore
+		generation, err = s.newListGeneration()
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", err
+	}
+	return fmt.Sprintf("ports:list:%s:%s", generation, query), nil
+}
+
+func (s *Service) newListGeneration() ([]byte, error) {
+	generation := []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+	err := s.cacheClient.Set(listGenerationCacheKey, generation)
+	if err != nil {
+		return nil, err
+	}
+	return generation, nil
+}
+
+// cachePort caches the port unless the cache has been invalidated since invalidations were counted, the port
+// could have been read before the change which invalidated it then.
+func (s *Service) cachePort(unlocode string, invalidations uint64, port []byte) error {
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+
+	if s.invalidations != invalidations {
+		return nil
+	}
+	return s.cacheClient.Set(portCacheKey(unlocode), port)
+}
+
+// cacheInvalidations returns the number of the invalidations so far, see cachePort.
+func (s *Service) cacheInvalidations() uint64 {
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+
+	return s.invalidations
+}
+
+// resetCache removes everything from the cache.
+func (s *Service) resetCache() error {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	s.invalidations++
+	return s.cacheClient.Reset()
+}
+
+// invalidatePort removes the cached port and the cached lists which could contain it.
+func (s *Service) invalidatePort(unlocode string) error {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	s.invalidations++
+	err := s.cacheClient.Delete(portCacheKey(unlocode))
+	if err != nil && !errors.Is(err, bigcache.ErrEntryNotFound) {
+		return err
+	}
+
+	_, err = s.newListGeneration()
+	return err
+}
@@ -0,0 +1,332 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/fir1/port/internal/port/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// createImport example
+//
+//	@Summary		Starts an import of a ports file in the background
+//	@Description	The file is stored and imported by a background job, the response is returned at once with the job ID.
+//	@Description	The file is JSON, NDJSON, CSV or TSV, told apart like by `POST /ports/from-file`.
+//	@Description	Instead of an upload, a JSON body `{"source_url": "https://...", "checksum": "sha256:..."}` imports
+//	@Description	the file of an HTTP or an HTTPS URL. The file is streamed to the import as it is downloaded, a dropped
+//	@Description	download is resumed and the failed requests are retried. The optional checksum is `<algorithm>:<hex digest>`
+//	@Description	with md5, sha1, sha256 or sha512, the import of a file which does not match it is rolled back.
+//	@Description	The job can be followed with `GET /imports/{id}` and cancelled with `DELETE /imports/{id}`.
+//	@Tags Imports
+//	@ID				create-import
+//	@Accept			mpfd,json
+//	@Produce		json
+//
+// @Param file formData file false "File, unless the source_url is in a JSON body"
+// @Param mode query string false "Import mode" Enums(strict, lenient)
+// @Param dry_run query bool false "Only report the diff"
+// @Param sync query bool false "Delete the ports missing from the file"
+// @Success      202 {object} service.ImportJob
+// @Failure      400 {object} ErrorResponse
+// @Failure      500
+// @Failure      503 {object} ErrorResponse
+// @Router			/imports [post].
+func (s *Service) createImport(w http.ResponseWriter, r *http.Request) {
+	var query importQuery
+	err := parseQueryParamsToStruct(r, &query)
+	if err != nil {
+		s.respondError(w, fmt.Sprintf("malformed query: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		s.createURLImport(w, r, query)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		s.respondError(w, "file not found", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	// the request body is gone when the job runs, so the upload is kept in a temporary file until the job has finished
+	path, err := storeUpload(file)
+	if err != nil {
+		s.respond(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	opts := query.options()
+	opts.Format = uploadFormat(header)
+	job, err := s.importJobs.Submit(service.FileSource{Path: path, Remove: true}, opts)
+	if err != nil {
+		os.Remove(path)
+		s.respondImportJobError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/imports/"+job.ID)
+	s.respond(w, job, http.StatusAccepted)
+}
+
+// importURLRequest is the body of an import of the file of a URL.
+type importURLRequest struct {
+	SourceURL string `json:"source_url"`
+	Checksum  string `json:"checksum"`
+}
+
+// createURLImport submits the import of the file of the URL in the body, the file is downloaded when the job runs.
+func (s *Service) createURLImport(w http.ResponseWriter, r *http.Request, query importQuery) {
+	var body importURLRequest
+	err := s.decode(r, &body)
+	if err != nil {
+		s.respondError(w, fmt.Sprintf("malformed body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	source, err := service.NewURLSource(s.config, body.SourceURL, body.Checksum)
+	if err != nil {
+		s.respondImportJobError(w, err)
+		return
+	}
+
+	job, err := s.importJobs.Submit(source, query.options())
+	if err != nil {
+		s.respondImportJobError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/imports/"+job.ID)
+	s.respond(w, job, http.StatusAccepted)
+}
+
+// getImport example
+//
+//	@Summary		Retrieves the state of an import job
+//	@Description	Retrieves the state, the progress and, when the job has finished, the report of the import.
+//	@Tags Imports
+//	@ID				get-import
+//	@Produce		json
+//	@Param			id	path		string	true	"Import job ID"
+//	@Success		200	{object}	service.ImportJob
+//	@Failure		404	{object}	ErrorResponse
+//	@Router			/imports/{id} [get].
+func (s *Service) getImport(w http.ResponseWriter, r *http.Request) {
+	job, err := s.importJobs.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		s.respondImportJobError(w, err)
+		return
+	}
+
+	s.respond(w, job, http.StatusOK)
+}
+
+// cancelImport example
+//
+//	@Summary		Cancels an import job
+//	@Description	A queued job never starts, the import of a running job is rolled back.
+//	@Description	The job is returned as it is when it is cancelled, it may still be running for a moment.
+//	@Tags Imports
+//	@ID				cancel-import
+//	@Produce		json
+//	@Param			id	path		string	true	"Import job ID"
+//	@Success		202	{object}	service.ImportJob
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		409	{object}	ErrorResponse
+//	@Router			/imports/{id} [delete].
+func (s *Service) cancelImport(w http.ResponseWriter, r *http.Request) {
+	job, err := s.importJobs.Cancel(chi.URLParam(r, "id"))
+	if err != nil {
+		s.respondImportJobError(w, err)
+		return
+	}
+
+	s.respond(w, job, http.StatusAccepted)
+}
+
+// importEventsInterval is how often the progress of an import job is sent to its event stream.
+const importEventsInterval = 500 * time.Millisecond
+
+// importProgressEvent is the data of a progress event of an import job.
+type importProgressEvent struct {
+	State service.ImportJobState `json:"state"`
+	service.ImportJobProgress
+}
+
+// importEvents example
+//
+//	@Summary		Streams the progress of an import job
+//	@Description	Streams Server-Sent Events until the job has finished. A `progress` event with the counters of the job
+//	@Description	is sent at once and then every half a second, an `error` event is sent with every record skipped
+//	@Description	by the import as it happens and a `done` event with the finished job ends the stream. The stream
+//	@Description	also ends without the `done` event when the server shuts down.
+//	@Tags Imports
+//	@ID				import-events
+//	@Produce		text/event-stream
+//	@Param			id	path		string	true	"Import job ID"
+//	@Success		200
+//	@Failure		404	{object}	ErrorResponse
+//	@Router			/imports/{id}/events [get].
+func (s *Service) importEvents(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.respondError(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// the errors are subscribed to before the job is read, so no error is missed in between
+	errs, unsubscribe, err := s.importJobs.Errors(id)
+	if err != nil {
+		s.respondImportJobError(w, err)
+		return
+	}
+	defer unsubscribe()
+
+	job, err := s.importJobs.Get(id)
+	if err != nil {
+		s.respondImportJobError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event string, data interface{}) bool {
+		err := writeEvent(w, event, data)
+		if err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	if !send("progress", importProgressEvent{State: job.State, ImportJobProgress: job.Progress}) {
+		return
+	}
+
+	ticker := time.NewTicker(importEventsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.streams.Done():
+			// the server shuts down, the job keeps running until the import jobs are closed
+			return
+		case recordErr, ok := <-errs:
+			if ok {
+				if !send("error", recordErr) {
+					return
+				}
+				continue
+			}
+
+			// the channel is closed when the job has finished
+			job, err = s.importJobs.Get(id)
+			if err != nil {
+				return
+			}
+			send("progress", importProgressEvent{State: job.State, ImportJobProgress: job.Progress})
+			send("done", job)
+			return
+		case <-ticker.C:
+			job, err = s.importJobs.Get(id)
+			if err != nil {
+				return
+			}
+			if !send("progress", importProgressEvent{State: job.State, ImportJobProgress: job.Progress}) {
+				return
+			}
+		}
+	}
+}
+
+// writeEvent writes a Server-Sent Event with the data encoded in JSON.
+func writeEvent(w io.Writer, event string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, body)
+	return err
+}
+
+func (s *Service) respondImportJobError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.As(err, &service.ErrInvalidQuery{}):
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &service.ErrImportJobNotFound{}):
+		s.respondError(w, err.Error(), http.StatusNotFound)
+	case errors.As(err, &service.ErrImportJobFinished{}):
+		s.respondError(w, err.Error(), http.StatusConflict)
+	case errors.As(err, &service.ErrImportQueueFull{}):
+		s.respondError(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		s.respond(w, err, http.StatusInternalServerError)
+	}
+}
+
+// importJobFinished clears the cache when a job has written the ports.
+func (s *Service) importJobFinished(job service.ImportJob) {
+	if job.State != service.ImportJobSucceeded || job.Options.DryRun {
+		return
+	}
+
+	err := s.resetCache()
+	if err != nil {
+		s.logger.Errorf("reset cache after import job %s: %v", job.ID, err)
+	}
+}
+
+// watchedImportFinished clears the cache when the watcher has imported a file and logs the result of the import.
+func (s *Service) watchedImportFinished(result service.WatchedImport) {
+	if result.Err != nil {
+		s.logger.Errorf("import watched file %s: %v", result.File, result.Err)
+	} else {
+		s.logger.Infof("imported watched file %s: %d created, %d updated, %d unchanged, %d skipped",
+			result.File, result.Report.Created, result.Report.Updated, result.Report.Unchanged, result.Report.Skipped)
+	}
+
+	// the error may only be the move of the file, so the report tells whether the ports have been written
+	written := result.Report.Created + result.Report.Updated + result.Report.Deleted
+	if result.Report.RolledBack || written == 0 {
+		return
+	}
+	err := s.resetCache()
+	if err != nil {
+		s.logger.Errorf("reset cache after import of watched file %s: %v", result.File, err)
+	}
+}
+
+// storeUpload copies the uploaded file to a temporary file and returns its path.
+func storeUpload(file io.Reader) (string, error) {
+	tmp, err := os.CreateTemp("", "ports-import-*.json")
+	if err != nil {
+		return "", fmt.Errorf("create upload file: %w", err)
+	}
+
+	_, err = io.Copy(tmp, file)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("store upload: %w", err)
+	}
+	return tmp.Name(), nil
+}
@@ -3,111 +3,343 @@ package http
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/allegro/bigcache/v3"
 	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/internal/port/repository"
+	"github.com/fir1/port/internal/port/service"
+	"github.com/fir1/port/internal/port/validation"
+	"github.com/fir1/port/pkg/geo"
+	"github.com/go-chi/chi/v5"
 )
 
+// importQuery is the query of the imports, `POST /ports` and `POST /ports/from-file`.
+type importQuery struct {
+	Mode   string `form:"mode"`
+	DryRun bool   `form:"dry_run"`
+	Sync   bool   `form:"sync"`
+}
+
+func (q importQuery) options() service.ImportOptions {
+	return service.ImportOptions{Mode: service.ImportMode(q.Mode), DryRun: q.DryRun, Sync: q.Sync}
+}
+
+// uploadFormat tells the format of an uploaded file by the content type of its part or the extension of its name.
+func uploadFormat(header *multipart.FileHeader) service.ImportFormat {
+	return service.DetectImportFormat(header.Header.Get("Content-Type"), header.Filename)
+}
+
+// importErrorResponse is the body returned when an import has been stopped and rolled back, the report tells
+// how far the import got.
+type importErrorResponse struct {
+	ErrorResponse
+	Report service.ImportReport `json:"report"`
+}
+
 // savePorts example
 //
 //	@Summary		It will create ports and save into the DB by default ports.json file will be used
-//	@Description	It will create ports and save into the DB by default ports.json file will be used
+//	@Description	It will create ports and save into the DB by default ports.json file will be used.
+//	@Description	In the strict mode (default) the import stops at the first invalid record, in the lenient mode
+//	@Description	the invalid records are skipped and listed in the report.
+//	@Description	A sync deletes the stored ports missing from the file.
+//	@Description	The import is written in a single transaction, a failed import leaves the stored ports intact.
 //	@Tags Ports
 //	@ID				save-ports
 //	@Accept			json
 //	@Produce		json
 //
-// @Success      201
+// @Param mode query string false "Import mode" Enums(strict, lenient)
+// @Param dry_run query bool false "Only report the diff"
+// @Param sync query bool false "Delete the ports missing from the file, all or nothing"
+// @Success      200 {object} service.ImportReport "Dry run"
+// @Success      201 {object} service.ImportReport
 //
-//	@Failure      400
+//	@Failure      400 {object} ErrorResponse
 //
+// @Failure      422 {object} importErrorResponse
 // @Failure      500
 // @Router			/ports [post].
 func (s *Service) savePorts(w http.ResponseWriter, r *http.Request) {
-	err := s.portService.SavePortsFromFile(r.Context(), "ports.json", nil)
+	var query importQuery
+	err := parseQueryParamsToStruct(r, &query)
 	if err != nil {
-		s.respond(w, err, http.StatusInternalServerError)
+		s.respondError(w, fmt.Sprintf("malformed query: %v", err), http.StatusBadRequest)
 		return
 	}
 
-	// we have updated list on DB so we have to clear cache
-	// so our API's must refetch the list
-	err = s.cacheClient.Reset()
-	if err != nil {
-		s.respond(w, err, http.StatusInternalServerError)
-		return
-	}
-
-	s.respond(w, nil, http.StatusCreated)
+	report, err := s.portService.SavePortsFromFile(r.Context(), "ports.json", nil, query.options())
+	s.respondImport(w, report, err)
 }
 
 // savePortsFromFile example
 //
 //	@Summary		You are able to provide json file the service will parse and save into the DB
-//	@Description	You are able to provide json file the service will parse and save into the DB
+//	@Description	You are able to provide json file the service will parse and save into the DB.
+//	@Description	In the strict mode (default) the import stops at the first invalid record, in the lenient mode
+//	@Description	the invalid records are skipped and listed in the report.
+//	@Description	A dry run compares the file with the stored ports and responds with the diff without writing anything.
+//	@Description	A sync deletes the stored ports missing from the file.
+//	@Description	The file is a JSON object of the ports keyed by their UN/LOCODE, NDJSON with a `{"code": ..., "port": {...}}`
+//	@Description	object on every line, CSV or TSV. The format is told by the content type of the file part, or by the extension of its name
+//	@Description	(`.json`, `.ndjson`, `.jsonl`, `.csv`, `.tsv`). Gzip and zstd files and zip archives are decompressed as they are read.
+//	@Description	The import is written in a single transaction, a failed import leaves the stored ports intact.
 //	@Tags Ports
 //	@ID				save-ports-from-file
 //	@Accept			mpfd
 //	@Produce		json
 //
 // @Param file formData file true "File"
-// @Success      201
+// @Param mode query string false "Import mode" Enums(strict, lenient)
+// @Param dry_run query bool false "Only report the diff"
+// @Param sync query bool false "Delete the ports missing from the file, all or nothing"
+// @Success      200 {object} service.ImportReport "Dry run"
+// @Success      201 {object} service.ImportReport
 //
-//	@Failure      400
+//	@Failure      400 {object} ErrorResponse
 //
+// @Failure      422 {object} importErrorResponse
 // @Failure      500
 // @Router			/ports/from-file [post].
 func (s *Service) savePortsFromFile(w http.ResponseWriter, r *http.Request) {
-	file, _, err := r.FormFile("file")
+	file, header, err := r.FormFile("file")
 	if err != nil {
 		http.Error(w, "file not found", http.StatusBadRequest)
 		return
 	}
 	defer file.Close()
 
-	err = s.portService.SavePortsFromFile(r.Context(), "", file)
+	var query importQuery
+	err = parseQueryParamsToStruct(r, &query)
 	if err != nil {
-		s.respond(w, err, http.StatusInternalServerError)
+		s.respondError(w, fmt.Sprintf("malformed query: %v", err), http.StatusBadRequest)
 		return
 	}
 
-	// we have updated list on DB so we have to clear cache
-	// so our API's must refetch the list
-	err = s.cacheClient.Reset()
+	opts := query.options()
+	opts.Format = uploadFormat(header)
+	report, err := s.portService.SavePortsFromFile(r.Context(), "", file, opts)
+	s.respondImport(w, report, err)
+}
+
+// putPortsBulk example
+//
+//	@Summary		Imports the ports streamed in the request body
+//	@Description	The body is decoded as it is received, without multipart encoding or temporary files, e.g.
+//	@Description	`curl -T ports.json.gz -H "Content-Encoding: gzip" http://localhost:8080/ports/bulk`.
+//	@Description	The format is told by the Content-Type: `application/json` (default), `application/x-ndjson`, `text/csv`
+//	@Description	or `text/tab-separated-values`. A gzip or a zstd body is decompressed by its Content-Encoding.
+//	@Description	The body may be at most `IMPORT_MAX_BODY_SIZE` bytes as it is sent. The request is checked before
+//	@Description	the body is read, so a client sending `Expect: 100-continue` does not send a body which would be refused.
+//	@Description	The modes, the dry run and the sync are the ones of `POST /ports/from-file`.
+//	@Tags Ports
+//	@ID				put-ports-bulk
+//	@Accept			json
+//	@Accept			application/x-ndjson
+//	@Accept			text/csv
+//	@Produce		json
+//
+// @Param ports body string true "Ports file"
+// @Param mode query string false "Import mode" Enums(strict, lenient)
+// @Param dry_run query bool false "Only report the diff"
+// @Param sync query bool false "Delete the ports missing from the body, all or nothing"
+// @Param Content-Encoding header string false "Compression of the body" Enums(identity, gzip, zstd)
+// @Success      200 {object} service.ImportReport "Dry run"
+// @Success      201 {object} service.ImportReport
+// @Failure      400 {object} ErrorResponse
+// @Failure      413 {object} importErrorResponse
+// @Failure      415 {object} ErrorResponse
+// @Failure      422 {object} importErrorResponse
+// @Failure      500
+// @Router			/ports/bulk [put].
+func (s *Service) putPortsBulk(w http.ResponseWriter, r *http.Request) {
+	// the request is checked before the body is read, the server only sends `100 Continue` when it is read.
+	// The body is not a form, so only the query of the URL is parsed, even for a form content type.
+	var format service.ImportFormat
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		var found bool
+		format, found = service.MediaTypeFormat(contentType)
+		if !found {
+			s.respondError(w, fmt.Sprintf("unsupported content type %q", contentType), http.StatusUnsupportedMediaType)
+			return
+		}
+	}
+
+	if maxSize := s.config.ImportMaxBodySize; maxSize > 0 {
+		if r.ContentLength > maxSize {
+			s.respondError(w, fmt.Sprintf("the body must be at most %d bytes", maxSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+	}
+
+	var query importQuery
+	err := parseURLQueryToStruct(r, &query)
 	if err != nil {
-		s.respond(w, err, http.StatusInternalServerError)
+		s.respondError(w, fmt.Sprintf("malformed query: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	opts := query.options()
+	opts.Format = format
+
+	report, err := s.portService.SavePortsFromStream(r.Context(), r.Body, r.Header.Get("Content-Encoding"), opts)
+	s.respondImport(w, report, err)
+}
+
+// respondImport responds with the report of an import. The cache is only cleared when the import has been committed,
+// a stopped import is rolled back and a dry run does not write anything.
+func (s *Service) respondImport(w http.ResponseWriter, report service.ImportReport, err error) {
+	if errors.As(err, &service.ErrInvalidQuery{}) {
+		s.respondError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if errors.As(err, &service.ErrUnsupportedEncoding{}) {
+		s.respondError(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	if err == nil && !report.DryRun {
+		// we have updated list on DB so we have to clear cache
+		// so our API's must refetch the list
+		cacheErr := s.resetCache()
+		if cacheErr != nil {
+			s.respond(w, cacheErr, http.StatusInternalServerError)
+			return
+		}
+	}
 
-	s.respond(w, nil, http.StatusCreated)
+	var (
+		validationErr validation.ValidationError
+		maxBytesErr   *http.MaxBytesError
+	)
+	switch {
+	case err == nil && report.DryRun:
+		s.respond(w, report, http.StatusOK)
+	case err == nil:
+		s.respond(w, report, http.StatusCreated)
+	case errors.As(err, &validationErr):
+		s.respond(w, importErrorResponse{
+			ErrorResponse: ErrorResponse{
+				Code:       http.StatusUnprocessableEntity,
+				Message:    validationErr.Error(),
+				Violations: validationErr.Violations,
+			},
+			Report: report,
+		}, http.StatusUnprocessableEntity)
+	case errors.As(err, &maxBytesErr):
+		s.respond(w, importErrorResponse{
+			ErrorResponse: ErrorResponse{
+				Code:    http.StatusRequestEntityTooLarge,
+				Message: fmt.Sprintf("the body must be at most %d bytes", maxBytesErr.Limit),
+			},
+			Report: report,
+		}, http.StatusRequestEntityTooLarge)
+	case errors.As(err, &service.ErrInvalidRecord{}):
+		s.respond(w, importErrorResponse{
+			ErrorResponse: ErrorResponse{Code: http.StatusUnprocessableEntity, Message: err.Error()},
+			Report:        report,
+		}, http.StatusUnprocessableEntity)
+	default:
+		s.respond(w, err, http.StatusInternalServerError)
+	}
+}
+
+// listPortsQuery is the query of `GET /ports`.
+type listPortsQuery struct {
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
+	Cursor string `form:"cursor"`
+	Sort   string `form:"sort"`
+
+	Country  string `form:"country"`
+	Province string `form:"province"`
+	City     string `form:"city"`
+	Timezone string `form:"timezone"`
+	Unloc    string `form:"unloc"`
+	Name     string `form:"name"`
+}
+
+// cacheKey returns the query in a canonical form, so the same filter written in a different case
+// or with the parameters in a different order is cached only once.
+func (q listPortsQuery) cacheKey() string {
+	values := url.Values{}
+	for key, value := range map[string]string{
+		"limit":    strconv.Itoa(q.Limit),
+		"offset":   strconv.Itoa(q.Offset),
+		"cursor":   q.Cursor,
+		"sort":     q.Sort,
+		"country":  strings.ToLower(strings.TrimSpace(q.Country)),
+		"province": strings.ToLower(strings.TrimSpace(q.Province)),
+		"city":     strings.ToLower(strings.TrimSpace(q.City)),
+		"timezone": strings.ToLower(strings.TrimSpace(q.Timezone)),
+		"unloc":    strings.ToLower(strings.TrimSpace(q.Unloc)),
+		"name":     strings.ToLower(strings.TrimSpace(q.Name)),
+	} {
+		if value != "" && value != "0" {
+			values.Set(key, value)
+		}
+	}
+	return values.Encode()
 }
 
 // listPorts example
 //
-//		@Summary		It will return all the available ports from the DB
-//		@Description	It will return all the available ports from the DB. We will use API caching for this purpose
+//		@Summary		It will return a page of the available ports from the DB
+//		@Description	It will return a page of the available ports from the DB. We will use API caching for this purpose
 //	 	@Description so we don't have to get all data over again from DB, which is useful in real world applications
 //	 	@Description where we are connected to the real database such as PostgresSQL it saves a lot of latency.
 //		@Description in case if the list empty, it means that you should call API endpoint `POST /ports` it
 //		@Description will parse `ports.json` file and saves into the DB, then you can make a call to `GET /ports`
-//		@Description to get all the available ports from the DB
+//		@Description to get all the available ports from the DB.
+//		@Description The ports are returned in a stable order, pass `next_cursor` of the response as `cursor`
+//		@Description to get the next page, there are no more ports when `next_cursor` is empty.
+//		@Description The ports can be filtered, all the filters are case-insensitive.
 //		@Tags Ports
 //		@ID			list-ports
 //		@Accept			json
 //		@Produce		json
 //
-// @Success      201
+// @Param limit query int false "Number of ports in the page, 100 by default and 1000 at most"
+// @Param cursor query string false "Cursor of the page, next_cursor of the previous page"
+// @Param offset query int false "Number of ports to skip, can not be used with the cursor"
+// @Param sort query string false "Sort by unlocode (default), code, name or country, prefix with - for descending order"
+// @Param country query string false "Country of the port" example(Germany)
+// @Param province query string false "Province of the port"
+// @Param city query string false "City of the port"
+// @Param timezone query string false "Timezone of the port" example(Asia/Dubai)
+// @Param unloc query string false "One of the UN/LOCODEs of the port"
+// @Param name query string false "Prefix of the port name"
+// @Success      200 {object} model.Page
 //
-//	@Failure      400
+//	@Failure      400 {object} ErrorResponse
 //
 // @Failure      500
 // @Router			/ports [get].
 func (s *Service) listPorts(w http.ResponseWriter, r *http.Request) {
-	cacheResponse, err := s.cacheClient.Get(r.RequestURI)
+	var query listPortsQuery
+	err := parseQueryParamsToStruct(r, &query)
+	if err != nil {
+		s.respondError(w, fmt.Sprintf("malformed query: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	cacheKey, err := s.listCacheKey(query.cacheKey())
+	if err != nil {
+		s.respond(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	cacheResponse, err := s.cacheClient.Get(cacheKey)
 	switch {
 	case err == nil:
-		response := map[string]model.Port{}
+		response := model.Page{}
 		err = json.Unmarshal(cacheResponse, &response)
 		if err != nil {
 			s.respond(w, err, http.StatusInternalServerError)
@@ -121,23 +353,480 @@ func (s *Service) listPorts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ports, err := s.portService.ListPorts(r.Context())
+	filter := model.PortFilter{
+		Country:    query.Country,
+		Province:   query.Province,
+		City:       query.City,
+		Timezone:   query.Timezone,
+		Unloc:      query.Unloc,
+		NamePrefix: query.Name,
+	}
+	page, err := s.portService.ListPortsPage(r.Context(), filter, model.PageRequest{
+		Limit:  query.Limit,
+		Offset: query.Offset,
+		Cursor: query.Cursor,
+		Sort:   query.Sort,
+	})
+	switch {
+	case err == nil:
+	case errors.As(err, &service.ErrInvalidQuery{}), errors.As(err, &repository.ErrInvalidCursor{}):
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	default:
+		s.respond(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	responseBytes, err := json.Marshal(&page)
 	if err != nil {
 		s.respond(w, err, http.StatusInternalServerError)
 		return
 	}
 
-	responseBytes, err := json.Marshal(&ports)
+	err = s.cacheClient.Set(cacheKey, responseBytes)
 	if err != nil {
 		s.respond(w, err, http.StatusInternalServerError)
 		return
 	}
 
-	err = s.cacheClient.Set(r.RequestURI, responseBytes)
+	s.respond(w, page, http.StatusOK)
+}
+
+// getPort example
+//
+//	@Summary		It will return a single port by its UN/LOCODE
+//	@Description	It will return a single port by its UN/LOCODE. Each port is cached on its own
+//	@Description	so the client can resolve one port without fetching the whole list.
+//	@Tags Ports
+//	@ID				get-port
+//	@Accept			json
+//	@Produce		json
+//
+// @Param unlocode path string true "UN/LOCODE of the port" example(AEAJM)
+// @Success      200 {object} model.Port
+// @Failure      404 {object} ErrorResponse
+// @Failure      500
+// @Router			/ports/{unlocode} [get].
+func (s *Service) getPort(w http.ResponseWriter, r *http.Request) {
+	unlocode := service.NormalizeUnlocode(chi.URLParam(r, "unlocode"))
+
+	cacheResponse, err := s.cacheClient.Get(portCacheKey(unlocode))
+	switch {
+	case err == nil:
+		response := model.Port{}
+		err = json.Unmarshal(cacheResponse, &response)
+		if err != nil {
+			s.respond(w, err, http.StatusInternalServerError)
+			return
+		}
+		s.respond(w, response, http.StatusOK)
+		return
+	case errors.Is(err, bigcache.ErrEntryNotFound):
+	default:
+		s.respond(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	// the invalidations are counted before the port is read, a port changed meanwhile is not cached
+	invalidations := s.cacheInvalidations()
+	port, err := s.portService.GetPort(r.Context(), unlocode)
+	switch {
+	case err == nil:
+	case errors.As(err, &repository.ErrObjectNotFound{}):
+		s.respondError(w, fmt.Sprintf("port %s not found", unlocode), http.StatusNotFound)
+		return
+	default:
+		s.respond(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	responseBytes, err := json.Marshal(&port)
 	if err != nil {
 		s.respond(w, err, http.StatusInternalServerError)
 		return
 	}
 
+	err = s.cachePort(unlocode, invalidations, responseBytes)
+	if err != nil {
+		s.respond(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.respond(w, port, http.StatusOK)
+}
+
+// putPort example
+//
+//	@Summary		It will create or fully replace a port
+//	@Description	It will create or fully replace the port stored under the UN/LOCODE.
+//	@Description	Only the cache of this port and the cached lists are invalidated.
+//	@Tags Ports
+//	@ID				put-port
+//	@Accept			json
+//	@Produce		json
+//
+// @Param unlocode path string true "UN/LOCODE of the port" example(AEAJM)
+// @Param port body model.Port true "Port"
+// @Success      200 {object} model.Port
+// @Success      201 {object} model.Port
+// @Failure      400 {object} ErrorResponse
+// @Failure      500
+// @Router			/ports/{unlocode} [put].
+func (s *Service) putPort(w http.ResponseWriter, r *http.Request) {
+	unlocode := service.NormalizeUnlocode(chi.URLParam(r, "unlocode"))
+
+	var port model.Port
+	err := s.decode(r, &port)
+	if err != nil {
+		s.respondError(w, fmt.Sprintf("malformed port: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// the port is stored with the current version, so it is responded with it too
+	err = port.Upgrade()
+	if err != nil {
+		s.respondError(w, fmt.Sprintf("malformed port: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var validationErr validation.ValidationError
+	created, err := s.portService.ReplacePort(r.Context(), unlocode, port)
+	switch {
+	case err == nil:
+	case errors.As(err, &validationErr):
+		s.respondValidationError(w, validationErr, http.StatusBadRequest)
+		return
+	case errors.As(err, &service.ErrInvalidPort{}):
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	default:
+		s.respond(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = s.invalidatePort(unlocode)
+	if err != nil {
+		s.respond(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if created {
+		s.respond(w, port, http.StatusCreated)
+		return
+	}
+	s.respond(w, port, http.StatusOK)
+}
+
+// patchPort example
+//
+//	@Summary		It will partially update a port
+//	@Description	It will apply JSON Merge Patch (RFC 7396) document to the port stored under the UN/LOCODE.
+//	@Description	Fields set to null are removed, the rest of the fields are replaced.
+//	@Tags Ports
+//	@ID				patch-port
+//	@Accept			json
+//	@Produce		json
+//
+// @Param unlocode path string true "UN/LOCODE of the port" example(AEAJM)
+// @Param patch body object true "JSON Merge Patch document"
+// @Success      200 {object} model.Port
+// @Failure      400 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      500
+// @Router			/ports/{unlocode} [patch].
+func (s *Service) patchPort(w http.ResponseWriter, r *http.Request) {
+	unlocode := service.NormalizeUnlocode(chi.URLParam(r, "unlocode"))
+
+	patch, err := s.readRequestBody(r)
+	if err != nil {
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var validationErr validation.ValidationError
+	port, err := s.portService.PatchPort(r.Context(), unlocode, patch)
+	switch {
+	case err == nil:
+	case errors.As(err, &validationErr):
+		s.respondValidationError(w, validationErr, http.StatusBadRequest)
+		return
+	case errors.As(err, &service.ErrInvalidPort{}):
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.As(err, &repository.ErrObjectNotFound{}):
+		s.respondError(w, fmt.Sprintf("port %s not found", unlocode), http.StatusNotFound)
+		return
+	default:
+		s.respond(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = s.invalidatePort(unlocode)
+	if err != nil {
+		s.respond(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.respond(w, port, http.StatusOK)
+}
+
+// deletePort example
+//
+//	@Summary		It will delete a port
+//	@Description	It will delete the port stored under the UN/LOCODE.
+//	@Tags Ports
+//	@ID				delete-port
+//	@Accept			json
+//	@Produce		json
+//
+// @Param unlocode path string true "UN/LOCODE of the port" example(AEAJM)
+// @Success      204
+// @Failure      404 {object} ErrorResponse
+// @Failure      500
+// @Router			/ports/{unlocode} [delete].
+func (s *Service) deletePort(w http.ResponseWriter, r *http.Request) {
+	unlocode := service.NormalizeUnlocode(chi.URLParam(r, "unlocode"))
+
+	err := s.portService.DeletePort(r.Context(), unlocode)
+	switch {
+	case err == nil:
+	case errors.As(err, &repository.ErrObjectNotFound{}):
+		s.respondError(w, fmt.Sprintf("port %s not found", unlocode), http.StatusNotFound)
+		return
+	default:
+		s.respond(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = s.invalidatePort(unlocode)
+	if err != nil {
+		s.respond(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.respond(w, nil, http.StatusNoContent)
+}
+
+// nearbyPortsQuery is the query of `GET /ports/nearby`.
+type nearbyPortsQuery struct {
+	Lat      *float64 `form:"lat"`
+	Lon      *float64 `form:"lon"`
+	RadiusKm float64  `form:"radius_km"`
+	Limit    int      `form:"limit"`
+}
+
+// nearbyPorts example
+//
+//	@Summary		It will return the ports around a location
+//	@Description	It will return the ports within the radius around the location sorted by the great-circle distance,
+//	@Description	the closest port first. The distance in kilometres is included in each port.
+//	@Tags Ports
+//	@ID				nearby-ports
+//	@Accept			json
+//	@Produce		json
+//
+// @Param lat query number true "Latitude of the location" example(25.27)
+// @Param lon query number true "Longitude of the location" example(55.3)
+// @Param radius_km query number false "Search radius in kilometres, 100 by default"
+// @Param limit query int false "Maximum number of ports, 10 by default and 1000 at most"
+// @Success      200 {array} model.NearbyPort
+// @Failure      400 {object} ErrorResponse
+// @Failure      500
+// @Router			/ports/nearby [get].
+func (s *Service) nearbyPorts(w http.ResponseWriter, r *http.Request) {
+	var query nearbyPortsQuery
+	err := parseQueryParamsToStruct(r, &query)
+	if err != nil {
+		s.respondError(w, fmt.Sprintf("malformed query: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if query.Lat == nil || query.Lon == nil {
+		s.respondError(w, "lat and lon are required", http.StatusBadRequest)
+		return
+	}
+
+	ports, err := s.portService.NearbyPorts(r.Context(), geo.Point{Lat: *query.Lat, Lon: *query.Lon}, query.RadiusKm, query.Limit)
+	switch {
+	case err == nil:
+	case errors.As(err, &service.ErrInvalidQuery{}):
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	default:
+		s.respond(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.respond(w, ports, http.StatusOK)
+}
+
+// portDistance example
+//
+//	@Summary		It will return the distance between two ports
+//	@Description	It will return the great-circle distance between two ports in kilometres and nautical miles
+//	@Description	and the initial bearing from the first port to the second one.
+//	@Tags Ports
+//	@ID				port-distance
+//	@Accept			json
+//	@Produce		json
+//
+// @Param unlocode path string true "UN/LOCODE of the port to measure from" example(AEAJM)
+// @Param to path string true "UN/LOCODE of the port to measure to" example(AEDXB)
+// @Success      200 {object} model.PortDistance
+// @Failure      404 {object} ErrorResponse
+// @Failure      422 {object} ErrorResponse
+// @Failure      500
+// @Router			/ports/{unlocode}/distance/{to} [get].
+func (s *Service) portDistance(w http.ResponseWriter, r *http.Request) {
+	from := strings.ToUpper(chi.URLParam(r, "unlocode"))
+	to := strings.ToUpper(chi.URLParam(r, "to"))
+
+	distance, err := s.portService.PortDistance(r.Context(), from, to)
+	if err != nil {
+		s.respondDistanceError(w, err)
+		return
+	}
+
+	s.respond(w, distance, http.StatusOK)
+}
+
+// distanceMatrixQuery is the query of `GET /ports/distance-matrix`.
+type distanceMatrixQuery struct {
+	Unlocodes string `form:"unlocodes"`
+}
+
+// distanceMatrix example
+//
+//	@Summary		It will return the distances between every pair of the ports
+//	@Description	It will return an N×N table of the great-circle distances in kilometres and nautical miles,
+//	@Description	the row i and the column j hold the distance from the i-th port to the j-th port.
+//	@Tags Ports
+//	@ID				distance-matrix
+//	@Accept			json
+//	@Produce		json
+//
+// @Param unlocodes query string true "Comma separated UN/LOCODEs, 100 at most" example(AEAJM,AEDXB,AEJEA)
+// @Success      200 {object} model.DistanceMatrix
+// @Failure      400 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      422 {object} ErrorResponse
+// @Failure      500
+// @Router			/ports/distance-matrix [get].
+func (s *Service) distanceMatrix(w http.ResponseWriter, r *http.Request) {
+	var query distanceMatrixQuery
+	err := parseQueryParamsToStruct(r, &query)
+	if err != nil {
+		s.respondError(w, fmt.Sprintf("malformed query: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	matrix, err := s.portService.DistanceMatrix(r.Context(), strings.Split(query.Unlocodes, ","))
+	if err != nil {
+		s.respondDistanceError(w, err)
+		return
+	}
+
+	s.respond(w, matrix, http.StatusOK)
+}
+
+func (s *Service) respondDistanceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.As(err, &service.ErrInvalidQuery{}):
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &service.ErrPortNotFound{}):
+		s.respondError(w, err.Error(), http.StatusNotFound)
+	case errors.As(err, &service.ErrMissingCoordinates{}):
+		s.respondError(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		s.respond(w, err, http.StatusInternalServerError)
+	}
+}
+
+// searchPortsQuery is the query of `GET /ports/search`.
+type searchPortsQuery struct {
+	Q     string `form:"q"`
+	Limit int    `form:"limit"`
+}
+
+// searchPorts example
+//
+//	@Summary		It will search the ports by their name, city, alias and province
+//	@Description	It will return the ports whose name, city, alias or province match the query, the best match first.
+//	@Description	The search ignores the case and the diacritics and tolerates typos, e.g. "abu dabi" finds Abu Dhabi.
+//	@Tags Ports
+//	@ID				search-ports
+//	@Accept			json
+//	@Produce		json
+//
+// @Param q query string true "Search query" example(Foochow)
+// @Param limit query int false "Maximum number of ports, 10 by default and 100 at most"
+// @Success      200 {array} model.SearchResult
+// @Failure      400 {object} ErrorResponse
+// @Failure      500
+// @Router			/ports/search [get].
+func (s *Service) searchPorts(w http.ResponseWriter, r *http.Request) {
+	var query searchPortsQuery
+	err := parseQueryParamsToStruct(r, &query)
+	if err != nil {
+		s.respondError(w, fmt.Sprintf("malformed query: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	ports, err := s.portService.SearchPorts(r.Context(), query.Q, query.Limit)
+	switch {
+	case err == nil:
+	case errors.As(err, &service.ErrInvalidQuery{}):
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	default:
+		s.respond(w, err, http.StatusInternalServerError)
+		return
+	}
+
 	s.respond(w, ports, http.StatusOK)
 }
+
+// autocompletePortsQuery is the query of `GET /ports/autocomplete`.
+type autocompletePortsQuery struct {
+	Prefix string `form:"prefix"`
+	Limit  int    `form:"limit"`
+}
+
+// autocompletePorts example
+//
+//	@Summary		It will suggest the ports for the typed prefix
+//	@Description	It will return the ports whose name, city, alias or UN/LOCODE starts with the prefix. Exact matches
+//	@Description	come first, then UN/LOCODE, name, city and alias matches. Any word of the name can be typed, e.g. "dhab"
+//	@Description	suggests Abu Dhabi.
+//	@Tags Ports
+//	@ID				autocomplete-ports
+//	@Accept			json
+//	@Produce		json
+//
+// @Param prefix query string true "Typed prefix" example(abu d)
+// @Param limit query int false "Maximum number of ports, 10 by default and 100 at most"
+// @Success      200 {array} model.Suggestion
+// @Failure      400 {object} ErrorResponse
+// @Failure      500
+// @Router			/ports/autocomplete [get].
+func (s *Service) autocompletePorts(w http.ResponseWriter, r *http.Request) {
+	var query autocompletePortsQuery
+	err := parseQueryParamsToStruct(r, &query)
+	if err != nil {
+		s.respondError(w, fmt.Sprintf("malformed query: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	suggestions, err := s.portService.AutocompletePorts(r.Context(), query.Prefix, query.Limit)
+	switch {
+	case err == nil:
+	case errors.As(err, &service.ErrInvalidQuery{}):
+		s.respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	default:
+		s.respond(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.respond(w, suggestions, http.StatusOK)
+}
@@ -8,9 +8,18 @@ import (
 	"net/http"
 	"sync"
 
+	"github.com/fir1/port/internal/port/validation"
 	"github.com/go-playground/form/v4"
 )
 
+// ErrorResponse is the body returned to the client when a request can not be served.
+type ErrorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	// Violations are the violations of the port when it does not pass the validation.
+	Violations []validation.Violation `json:"violations,omitempty"`
+}
+
 /*
 Don’t have to repeat yourself every time you respond to user, instead you can use some helper functions.
 */
@@ -26,8 +35,8 @@ func (s *Service) respond(w http.ResponseWriter, data interface{}, status int) {
 		respData = data
 	}
 
-	w.WriteHeader(status)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 
 	if respData != nil {
 		err := json.NewEncoder(w).Encode(respData)
@@ -38,6 +47,16 @@ func (s *Service) respond(w http.ResponseWriter, data interface{}, status int) {
 	}
 }
 
+// respondError responds with a structured ErrorResponse body, unlike respond which only sets the status for errors.
+func (s *Service) respondError(w http.ResponseWriter, message string, status int) {
+	s.respond(w, ErrorResponse{Code: status, Message: message}, status)
+}
+
+// respondValidationError responds with an ErrorResponse which lists every violation of the port.
+func (s *Service) respondValidationError(w http.ResponseWriter, err validation.ValidationError, status int) {
+	s.respond(w, ErrorResponse{Code: status, Message: err.Error(), Violations: err.Violations}, status)
+}
+
 // it does not read to the memory, instead it will read it to the given 'v' interface.
 func (s *Service) decode(r *http.Request, v interface{}) error {
 	return json.NewDecoder(r.Body).Decode(v)
@@ -83,3 +102,13 @@ func parseQueryParamsToStruct(r *http.Request, strType interface{}) error {
 	err = decoder.Decode(&strType, r.Form)
 	return err
 }
+
+// parseURLQueryToStruct decodes the query of the URL only, unlike parseQueryParamsToStruct it never reads
+// a form from the body, so the body of the request is left untouched.
+func parseURLQueryToStruct(r *http.Request, strType interface{}) error {
+	initOnce.Do(func() {
+		decoder = form.NewDecoder()
+	})
+
+	return decoder.Decode(&strType, r.URL.Query())
+}
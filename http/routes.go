@@ -3,6 +3,20 @@ package http
 func (s *Service) routes() {
 	s.router.Get("/health", s.GetHealth)
 	s.router.Get("/ports", s.listPorts)
+	s.router.Get("/ports/nearby", s.nearbyPorts)
+	s.router.Get("/ports/distance-matrix", s.distanceMatrix)
+	s.router.Get("/ports/search", s.searchPorts)
+	s.router.Get("/ports/autocomplete", s.autocompletePorts)
+	s.router.Put("/ports/bulk", s.putPortsBulk)
+	s.router.Get("/ports/{unlocode}", s.getPort)
+	s.router.Put("/ports/{unlocode}", s.putPort)
+	s.router.Patch("/ports/{unlocode}", s.patchPort)
+	s.router.Delete("/ports/{unlocode}", s.deletePort)
+	s.router.Get("/ports/{unlocode}/distance/{to}", s.portDistance)
 	s.router.Post("/ports", s.savePorts)
 	s.router.Post("/ports/from-file", s.savePortsFromFile)
+	s.router.Post("/imports", s.createImport)
+	s.router.Get("/imports/{id}", s.getImport)
+	s.router.Get("/imports/{id}/events", s.importEvents)
+	s.router.Delete("/imports/{id}", s.cancelImport)
 }
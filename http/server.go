@@ -39,7 +39,7 @@ func (s *Service) Run(stop chan struct{}) error {
 	s.router.Use(
 		cors.Handler(cors.Options{
 			AllowedOrigins:     []string{"*"}, //TODO: must be changed to allow only prod, dev hosts.
-			AllowedMethods:     []string{"GET", "POST", "HEAD", "PATCH", "OPTIONS", "GET", "PUT"},
+			AllowedMethods:     []string{"GET", "POST", "HEAD", "PATCH", "OPTIONS", "GET", "PUT", "DELETE"},
 			AllowedHeaders:     []string{"*"},
 			ExposedHeaders:     nil,
 			AllowCredentials:   true,
@@ -68,6 +68,7 @@ func (s *Service) Run(stop chan struct{}) error {
 		Addr:    fmt.Sprintf(":%d", s.config.Port),
 		Handler: s.router,
 	}
+	server.RegisterOnShutdown(s.closeStreams)
 
 	// channel to listen for errors coming from the listener.
 	serverErrors := make(chan error, 1)
@@ -92,8 +93,10 @@ func (s *Service) Run(stop chan struct{}) error {
 		return fmt.Errorf("error: starting REST API http: %w", err)
 	case <-stop:
 		s.logger.Warn("http receive STOP signal")
-		// asking listener to shutdown
-		err := server.Shutdown(context.Background())
+		// asking listener to shutdown, the requests still running after the timeout are dropped
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+		defer cancel()
+		err := server.Shutdown(ctx)
 		if err != nil {
 			return fmt.Errorf("graceful shutdown did not complete: %w", err)
 		}
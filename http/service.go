@@ -1,6 +1,9 @@
 package http
 
 import (
+	"context"
+	"sync"
+
 	"github.com/fir1/port/config"
 	"github.com/fir1/port/internal/port/service"
 	"github.com/fir1/port/pkg/cache"
@@ -17,17 +20,33 @@ type Service struct {
 	config            config.Config
 	cacheClient       cache.CacheClientInterface
 	portService       service.PortService
+	importJobs        *service.ImportJobs
+	// streams is done when the server shuts down, the event streams which last until their job has finished
+	// end then, so they do not hold the shutdown
+	streams      context.Context
+	closeStreams context.CancelFunc
+	// invalidations counts the invalidations of the cache, a port read before one of them must not be cached after
+	// it. cacheMu orders the invalidations with the caching of a port.
+	cacheMu       sync.RWMutex
+	invalidations uint64
 }
 
 func NewService(logger *logrus.Logger,
 	cnf config.Config,
 	cc cache.CacheClientInterface,
 	ps service.PortService,
+	jobs *service.ImportJobs,
+	watcher *service.ImportWatcher,
 ) *Service {
-	return &Service{
+	s := &Service{
 		logger:      logger,
 		config:      cnf,
 		cacheClient: cc,
 		portService: ps,
+		importJobs:  jobs,
 	}
+	s.streams, s.closeStreams = context.WithCancel(context.Background())
+	jobs.OnFinished(s.importJobFinished)
+	watcher.OnImported(s.watchedImportFinished)
+	return s
 }
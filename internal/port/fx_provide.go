@@ -1,12 +1,100 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+
+	"github.com/fir1/port/config"
 	"github.com/fir1/port/internal/port/repository"
 	"github.com/fir1/port/internal/port/service"
 	"go.uber.org/fx"
+
+	// registers pure-Go "sqlite" driver for database/sql
+	_ "modernc.org/sqlite"
 )
 
 var FxProvide = fx.Provide(
 	service.NewPortService,
-	repository.NewPostRepositoryMemoryDB,
+	newRepository,
+	newImportJobs,
+	newImportWatcher,
 )
+
+// newImportJobs provides the manager of the import jobs, the running jobs are cancelled on stop.
+func newImportJobs(lc fx.Lifecycle, ps service.PortService, cnf config.Config) *service.ImportJobs {
+	jobs := service.NewImportJobs(ps, cnf)
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			jobs.Close()
+			return nil
+		},
+	})
+	return jobs
+}
+
+// newImportWatcher provides the watcher of the data directory, it is only started when IMPORT_WATCH is set.
+func newImportWatcher(lc fx.Lifecycle, ps service.PortService, cnf config.Config) *service.ImportWatcher {
+	watcher := service.NewImportWatcher(ps, cnf)
+	if !cnf.ImportWatch {
+		return watcher
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return watcher.Start()
+		},
+		OnStop: func(ctx context.Context) error {
+			watcher.Close()
+			return nil
+		},
+	})
+	return watcher
+}
+
+// newRepository provides the repository backend chosen by the REPOSITORY_DRIVER config.
+func newRepository(lc fx.Lifecycle, cnf config.Config) (repository.PostRepositoryInterface, error) {
+	switch cnf.RepositoryDriver {
+	case config.RepositoryDriverMemory:
+		return repository.NewPostRepositoryMemoryDB(), nil
+	case config.RepositoryDriverFile:
+		rp, err := repository.NewPostRepositoryFileDB(filepath.Join(cnf.DataDir, "portdb"))
+		if err != nil {
+			return nil, err
+		}
+		lc.Append(fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				return rp.Close()
+			},
+		})
+		return rp, nil
+	case config.RepositoryDriverSQL:
+		dataSource := cnf.SQLDataSource
+		if dataSource == "" {
+			// WAL lets readers see the last committed data while a writer is active,
+			// busy timeout makes concurrent writers wait for each other instead of failing
+			dataSource = fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=busy_timeout(5000)",
+				filepath.Join(cnf.DataDir, "ports.db"))
+		}
+
+		db, err := sql.Open(cnf.SQLDriver, dataSource)
+		if err != nil {
+			return nil, fmt.Errorf("open database: %w", err)
+		}
+
+		rp, err := repository.NewPostRepositorySQL(context.Background(), db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		lc.Append(fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				return rp.Close()
+			},
+		})
+		return rp, nil
+	default:
+		return nil, fmt.Errorf("unknown repository driver %q", cnf.RepositoryDriver)
+	}
+}
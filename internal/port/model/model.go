@@ -1,14 +1,161 @@
 package model
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// PortVersion is the version of the port documents, documents without the version are legacy documents
+// whose alias and regions were not typed. The alias and the regions of the legacy documents are converted when they
+// are decoded, so the existing ports files can still be imported.
+const PortVersion = 2
+
 type Port struct {
-	Name        string        `json:"name"`
-	City        string        `json:"city"`
-	Country     string        `json:"country"`
-	Alias       []interface{} `json:"alias"`
-	Regions     []interface{} `json:"regions"`
-	Coordinates []float64     `json:"coordinates"`
-	Province    string        `json:"province"`
-	Timezone    string        `json:"timezone"`
-	Unlocs      []string      `json:"unlocs"`
-	Code        string        `json:"code"`
+	Version     int       `json:"version,omitempty"`
+	Name        string    `json:"name"`
+	City        string    `json:"city"`
+	Country     string    `json:"country"`
+	Alias       []string  `json:"alias"`
+	Regions     []Region  `json:"regions"`
+	Coordinates []float64 `json:"coordinates"`
+	Province    string    `json:"province"`
+	Timezone    string    `json:"timezone"`
+	Unlocs      []string  `json:"unlocs"`
+	Code        string    `json:"code"`
+}
+
+// UnmarshalJSON decodes the port document. The aliases of a legacy document are converted, the ones
+// of a current document must be strings, see decodeAliases.
+func (p *Port) UnmarshalJSON(data []byte) error {
+	// port has no UnmarshalJSON, so the document is decoded field by field, the alias is decoded
+	// once the version is known
+	type port Port
+	var decoded struct {
+		port
+		Alias json.RawMessage `json:"alias"`
+	}
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return err
+	}
+
+	*p = Port(decoded.port)
+	if decoded.Alias == nil {
+		return nil
+	}
+	p.Alias, err = decodeAliases(decoded.Alias, p.Version < PortVersion)
+	return err
+}
+
+// decodeAliases decodes the aliases, which must be strings. The aliases of a legacy document may also be numbers,
+// e.g. the code of the port, or null: a number becomes its text, e.g. `35700` becomes "35700" and `1.0`
+// becomes "1", and a null alias is dropped.
+func decodeAliases(data []byte, legacy bool) ([]string, error) {
+	var values []interface{}
+	err := json.Unmarshal(data, &values)
+	if err != nil {
+		return nil, fmt.Errorf("alias must be a list of strings, got %s", data)
+	}
+	if values == nil {
+		return nil, nil
+	}
+
+	aliases := make([]string, 0, len(values))
+	for _, value := range values {
+		switch v := value.(type) {
+		case string:
+			aliases = append(aliases, v)
+		case nil:
+			if !legacy {
+				return nil, errors.New("alias must be a string, got null")
+			}
+		case float64:
+			if !legacy {
+				return nil, fmt.Errorf("alias must be a string, got %v", v)
+			}
+			aliases = append(aliases, strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			return nil, fmt.Errorf("alias must be a string, got %v", v)
+		}
+	}
+	return aliases, nil
+}
+
+// ErrUnsupportedVersion is returned for a port document written by a newer version of the service.
+type ErrUnsupportedVersion struct {
+	Version int
+}
+
+func (e ErrUnsupportedVersion) Error() string {
+	return fmt.Sprintf("unsupported port version %d, the latest version is %d", e.Version, PortVersion)
+}
+
+// Upgrade marks the port decoded from a legacy document with the current PortVersion,
+// ErrUnsupportedVersion is returned when the port has a newer version.
+func (p *Port) Upgrade() error {
+	if p.Version < 0 || p.Version > PortVersion {
+		return ErrUnsupportedVersion{Version: p.Version}
+	}
+	p.Version = PortVersion
+	return nil
+}
+
+// Location returns the latitude and longitude from the Coordinates, which are stored as [lon, lat].
+// It reports false when the port has no valid coordinates.
+func (p Port) Location() (lat, lon float64, ok bool) {
+	if len(p.Coordinates) != 2 {
+		return 0, 0, false
+	}
+
+	lon, lat = p.Coordinates[0], p.Coordinates[1]
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+// Region is a region the port belongs to.
+type Region struct {
+	ID   int    `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// UnmarshalJSON decodes the region object, or converts the region of a legacy document, which is either
+// its numeric ID, e.g. `3` or `"3"`, or its name.
+func (r *Region) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case nil:
+		// null is a no-op by the convention of json.Unmarshaler
+	case float64:
+		if v != float64(int(v)) {
+			return fmt.Errorf("region ID must be an integer, got %s", data)
+		}
+		*r = Region{ID: int(v)}
+	case string:
+		if id, err := strconv.Atoi(v); err == nil {
+			*r = Region{ID: id}
+		} else {
+			*r = Region{Name: v}
+		}
+	case map[string]interface{}:
+		// region has no UnmarshalJSON, so the object is decoded field by field
+		type region Region
+		var decoded region
+		err = json.Unmarshal(data, &decoded)
+		if err != nil {
+			return err
+		}
+		*r = Region(decoded)
+	default:
+		return fmt.Errorf("region must be an object, an ID or a name, got %s", data)
+	}
+	return nil
 }
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPort_UnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		name          string
+		data          string
+		expected      Port
+		expectedError bool
+	}{
+		{
+			name: "LegacyDocument",
+			data: `{"name":"Ajman","alias":["test",35700,1.0,2.5,null],"regions":[1,"2","Gulf",null],"unlocs":["AEAJM"]}`,
+			expected: Port{
+				Name:    "Ajman",
+				Alias:   []string{"test", "35700", "1", "2.5"},
+				Regions: []Region{{ID: 1}, {ID: 2}, {Name: "Gulf"}, {}},
+				Unlocs:  []string{"AEAJM"},
+			},
+		},
+		{
+			name: "CurrentVersion",
+			data: `{"version":2,"name":"Ajman","alias":["test"],"regions":[{"id":1,"name":"Gulf"}]}`,
+			expected: Port{
+				Version: PortVersion,
+				Name:    "Ajman",
+				Alias:   []string{"test"},
+				Regions: []Region{{ID: 1, Name: "Gulf"}},
+			},
+		},
+		{
+			name: "LegacyVersion",
+			data: `{"version":1,"name":"Ajman","alias":[35700]}`,
+			expected: Port{
+				Version: 1,
+				Name:    "Ajman",
+				Alias:   []string{"35700"},
+			},
+		},
+		{
+			name:          "FailureObjectAlias",
+			data:          `{"name":"Ajman","alias":[{"name":"test"}]}`,
+			expectedError: true,
+		},
+		{
+			name:          "FailureBooleanAlias",
+			data:          `{"name":"Ajman","alias":[true]}`,
+			expectedError: true,
+		},
+		{
+			name:          "FailureNumberAliasCurrentVersion",
+			data:          `{"version":2,"name":"Ajman","alias":[35700]}`,
+			expectedError: true,
+		},
+		{
+			name:          "FailureNullAliasCurrentVersion",
+			data:          `{"version":2,"name":"Ajman","alias":["test",null]}`,
+			expectedError: true,
+		},
+		{
+			name:          "FailureFractionalRegion",
+			data:          `{"name":"Ajman","regions":[1.5]}`,
+			expectedError: true,
+		},
+		{
+			name:          "FailureArrayRegion",
+			data:          `{"name":"Ajman","regions":[[1]]}`,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var port Port
+			err := json.Unmarshal([]byte(tc.data), &port)
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, port)
+		})
+	}
+}
+
+func TestPort_Upgrade(t *testing.T) {
+	port := Port{Name: "Ajman"}
+	require.NoError(t, port.Upgrade())
+	assert.Equal(t, PortVersion, port.Version)
+
+	encoded, err := json.Marshal(port)
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"version":2`)
+
+	port.Version = PortVersion + 1
+	assert.ErrorAs(t, port.Upgrade(), &ErrUnsupportedVersion{})
+}
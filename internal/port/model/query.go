@@ -0,0 +1,135 @@
+package model
+
+import "strings"
+
+// Fields the ports can be sorted by, prefix the field with "-" to sort in descending order, e.g. "-name".
+// Ports with the same value are always ordered by their UN/LOCODE, so the order is stable between pages.
+const (
+	SortByUnlocode = "unlocode"
+	SortByCode     = "code"
+	SortByName     = "name"
+	SortByCountry  = "country"
+)
+
+// PortRecord is a port together with the UN/LOCODE it is stored under.
+type PortRecord struct {
+	Unlocode string `json:"unlocode"`
+	Port
+}
+
+// PageRequest describes which page of ports should be returned. Cursor is the NextCursor of the previous page,
+// Offset is only used when there is no Cursor.
+type PageRequest struct {
+	Limit  int
+	Offset int
+	Cursor string
+	Sort   string
+}
+
+// SortField returns the field the ports are sorted by and whether the order is descending.
+func (p PageRequest) SortField() (string, bool) {
+	if strings.HasPrefix(p.Sort, "-") {
+		return strings.TrimPrefix(p.Sort, "-"), true
+	}
+	return p.Sort, false
+}
+
+// Page is a page of ports, NextCursor is empty when there are no more ports left.
+type Page struct {
+	Items      []PortRecord `json:"items"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// PortFilter narrows down the listed ports. Every field is matched case-insensitively and empty fields are ignored.
+type PortFilter struct {
+	Country  string
+	Province string
+	City     string
+	Timezone string
+	// Unloc matches the ports which have it in their Unlocs.
+	Unloc string
+	// NamePrefix matches the ports which name starts with it.
+	NamePrefix string
+}
+
+// IsEmpty reports whether the filter matches every port.
+func (f PortFilter) IsEmpty() bool {
+	return f == PortFilter{}
+}
+
+// Matches reports whether the port passes the filter.
+func (f PortFilter) Matches(port Port) bool {
+	if f.Country != "" && !strings.EqualFold(port.Country, f.Country) {
+		return false
+	}
+	if f.Province != "" && !strings.EqualFold(port.Province, f.Province) {
+		return false
+	}
+	if f.City != "" && !strings.EqualFold(port.City, f.City) {
+		return false
+	}
+	if f.Timezone != "" && !strings.EqualFold(port.Timezone, f.Timezone) {
+		return false
+	}
+	if f.NamePrefix != "" && !strings.HasPrefix(strings.ToLower(port.Name), strings.ToLower(f.NamePrefix)) {
+		return false
+	}
+	if f.Unloc != "" {
+		for _, unloc := range port.Unlocs {
+			if strings.EqualFold(unloc, f.Unloc) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// NearbyPort is a port found around a location together with its great-circle distance to the location.
+type NearbyPort struct {
+	PortRecord
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// PortDistance is the great-circle distance between two ports.
+type PortDistance struct {
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	DistanceKm float64 `json:"distance_km"`
+	DistanceNm float64 `json:"distance_nm"`
+	// InitialBearing is the bearing at the From port in degrees clockwise from the true north.
+	InitialBearing float64 `json:"initial_bearing"`
+}
+
+// DistanceMatrix holds the distances between every pair of the ports, DistancesKm[i][j] is the distance
+// from Unlocodes[i] to Unlocodes[j].
+type DistanceMatrix struct {
+	Unlocodes   []string    `json:"unlocodes"`
+	DistancesKm [][]float64 `json:"distances_km"`
+	DistancesNm [][]float64 `json:"distances_nm"`
+}
+
+// SearchResult is a port found by the full-text search, the better match has the higher score.
+type SearchResult struct {
+	PortRecord
+	Score float64 `json:"score"`
+}
+
+// The kinds of the text which matched the autocomplete prefix.
+const (
+	MatchTypeCode  = "code"
+	MatchTypeName  = "name"
+	MatchTypeCity  = "city"
+	MatchTypeAlias = "alias"
+)
+
+// Suggestion is a port suggested for the autocomplete prefix.
+type Suggestion struct {
+	PortRecord
+	// Match is the text of the port which starts with the prefix.
+	Match string `json:"match"`
+	// MatchType is one of the MatchType constants.
+	MatchType string `json:"match_type"`
+	// Exact is true when the whole text equals the prefix.
+	Exact bool `json:"exact"`
+}
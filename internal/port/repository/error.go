@@ -6,3 +6,28 @@ type ErrObjectNotFound struct {
 func (o ErrObjectNotFound) Error() string {
 	return "object not found"
 }
+
+// ErrInvalidCursor is returned when the page cursor is malformed or was issued for a different sort.
+type ErrInvalidCursor struct {
+}
+
+func (o ErrInvalidCursor) Error() string {
+	return "invalid cursor"
+}
+
+// ErrTransactionDone is returned when a transaction is used after it has been committed or rolled back.
+type ErrTransactionDone struct {
+}
+
+func (o ErrTransactionDone) Error() string {
+	return "transaction has already been committed or rolled back"
+}
+
+// ErrRepositoryLocked is returned when the directory of a file repository is already used by an open repository.
+type ErrRepositoryLocked struct {
+	Dir string
+}
+
+func (o ErrRepositoryLocked) Error() string {
+	return "repository " + o.Dir + " is already open by another process"
+}
@@ -0,0 +1,419 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fir1/port/internal/port/model"
+)
+
+const (
+	fileDBSnapshotName = "snapshot.json"
+	fileDBJournalName  = "journal.log"
+	fileDBLockName     = "LOCK"
+
+	// fileDBCompactThreshold is the number of journal records after which the journal is folded into the snapshot.
+	fileDBCompactThreshold = 10000
+
+	journalOpPut    = "put"
+	journalOpDelete = "delete"
+	// journalOpBatch is a committed transaction, its records are written on a single line, so a crash
+	// can not leave a part of the transaction in the journal
+	journalOpBatch = "batch"
+)
+
+type journalRecord struct {
+	Op      string          `json:"op"`
+	Key     string          `json:"key,omitempty"`
+	Port    *model.Port     `json:"port,omitempty"`
+	Records []journalRecord `json:"records,omitempty"`
+}
+
+// PostRepositoryFileDB is an embedded persistent repository. All the ports are served from memory, every change is
+// appended to a journal file before it is applied, and from time to time the journal is compacted into a snapshot.
+// On start the snapshot is loaded and the journal is replayed on top of it, so no data is lost between restarts.
+// Journal records are written straight to the file, so they survive a crash of the process, they are synced to
+// the disk when the journal is compacted and when the repository is closed. The directory is locked while
+// the repository is open, so a second process can not open it.
+type PostRepositoryFileDB struct {
+	*PostRepositoryMemoryDB
+
+	dir            string
+	lock           *os.File
+	journal        journalFile
+	journalRecords int
+	// journalSize is the size of the complete records of the journal, a failed write is cut back to it
+	journalSize int64
+	// journalErr is set when a failed write could not be cut back, the journal is then not written anymore
+	// until it is compacted
+	journalErr error
+	// writeMu keeps the order of the journal records the same as the order of changes applied to the memory.
+	writeMu sync.Mutex
+}
+
+// journalFile is the file the journal records are appended to.
+type journalFile interface {
+	io.WriteCloser
+	Truncate(size int64) error
+}
+
+// NewPostRepositoryFileDB opens the repository stored in dir, the directory is created if it does not exist.
+// It is ErrRepositoryLocked when the repository is already open, e.g. by another process.
+func NewPostRepositoryFileDB(dir string) (*PostRepositoryFileDB, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("create repository dir: %w", err)
+	}
+
+	lock, err := lockDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &PostRepositoryFileDB{
+		PostRepositoryMemoryDB: newPostRepositoryMemoryDB(),
+		dir:                    dir,
+		lock:                   lock,
+	}
+
+	err = r.open()
+	if err != nil {
+		if r.journal != nil {
+			r.journal.Close()
+		}
+		lock.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *PostRepositoryFileDB) open() error {
+	err := r.loadSnapshot()
+	if err != nil {
+		return err
+	}
+
+	err = r.replayJournal()
+	if err != nil {
+		return err
+	}
+
+	// start with an empty journal, everything replayed so far goes into the snapshot
+	return r.compact()
+}
+
+func (r *PostRepositoryFileDB) Create(ctx context.Context, key string, entity model.Port) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	err := r.appendJournal(journalRecord{Op: journalOpPut, Key: key, Port: &entity})
+	if err != nil {
+		return err
+	}
+
+	err = r.PostRepositoryMemoryDB.Create(ctx, key, entity)
+	if err != nil {
+		return err
+	}
+	return r.compactIfNeeded()
+}
+
+func (r *PostRepositoryFileDB) Update(ctx context.Context, key string, entity model.Port) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	err := r.appendJournal(journalRecord{Op: journalOpPut, Key: key, Port: &entity})
+	if err != nil {
+		return err
+	}
+
+	err = r.PostRepositoryMemoryDB.Update(ctx, key, entity)
+	if err != nil {
+		return err
+	}
+	return r.compactIfNeeded()
+}
+
+func (r *PostRepositoryFileDB) Delete(ctx context.Context, key string) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	_, err := r.PostRepositoryMemoryDB.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	err = r.appendJournal(journalRecord{Op: journalOpDelete, Key: key})
+	if err != nil {
+		return err
+	}
+
+	err = r.PostRepositoryMemoryDB.Delete(ctx, key)
+	if err != nil {
+		return err
+	}
+	return r.compactIfNeeded()
+}
+
+// Modify calls update while the other writes wait, the port is journaled before it is stored like by Update.
+func (r *PostRepositoryFileDB) Modify(ctx context.Context, key string, update func(port model.Port, found bool) (model.Port, error)) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	port, err := r.PostRepositoryMemoryDB.Get(ctx, key)
+	found := err == nil
+	updated, err := update(port, found)
+	if err != nil {
+		return err
+	}
+
+	err = r.appendJournal(journalRecord{Op: journalOpPut, Key: key, Port: &updated})
+	if err != nil {
+		return err
+	}
+
+	err = r.PostRepositoryMemoryDB.Update(ctx, key, updated)
+	if err != nil {
+		return err
+	}
+	return r.compactIfNeeded()
+}
+
+// Begin starts a transaction, it is committed as a single journal record.
+func (r *PostRepositoryFileDB) Begin(ctx context.Context) (Transaction, error) {
+	return newStagedTransaction(r), nil
+}
+
+func (r *PostRepositoryFileDB) apply(changes map[string]*model.Port) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	batch := journalRecord{Op: journalOpBatch, Records: make([]journalRecord, 0, len(changes))}
+	for key, port := range changes {
+		if port == nil {
+			batch.Records = append(batch.Records, journalRecord{Op: journalOpDelete, Key: key})
+			continue
+		}
+		batch.Records = append(batch.Records, journalRecord{Op: journalOpPut, Key: key, Port: port})
+	}
+
+	err := r.appendJournal(batch)
+	if err != nil {
+		return err
+	}
+
+	err = r.PostRepositoryMemoryDB.apply(changes)
+	if err != nil {
+		return err
+	}
+	return r.compactIfNeeded()
+}
+
+// Close compacts the journal into the snapshot and releases the journal file and the lock of the directory.
+func (r *PostRepositoryFileDB) Close() error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	err := r.compact()
+	if err != nil {
+		return err
+	}
+	err = r.journal.Close()
+	if err != nil {
+		return err
+	}
+	return r.lock.Close()
+}
+
+func (r *PostRepositoryFileDB) appendJournal(record journalRecord) error {
+	if r.journalErr != nil {
+		return r.journalErr
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encode journal record: %w", err)
+	}
+	line = append(line, '\n')
+
+	_, err = r.journal.Write(line)
+	if err != nil {
+		// a part of the record may have been written, it is cut off, so the next records do not follow
+		// a corrupt line which would stop the journal from being replayed
+		truncateErr := r.journal.Truncate(r.journalSize)
+		if truncateErr != nil {
+			r.journalErr = fmt.Errorf("journal is corrupt after a failed write: %w", truncateErr)
+		}
+		return fmt.Errorf("write journal record: %w", err)
+	}
+	r.journalRecords++
+	r.journalSize += int64(len(line))
+	return nil
+}
+
+func (r *PostRepositoryFileDB) compactIfNeeded() error {
+	if r.journalRecords < fileDBCompactThreshold {
+		return nil
+	}
+	return r.compact()
+}
+
+func (r *PostRepositoryFileDB) compact() error {
+	ports, err := r.PostRepositoryMemoryDB.ListAll(context.Background())
+	if err != nil {
+		return err
+	}
+	return r.writeSnapshot(ports)
+}
+
+// writeSnapshot atomically replaces the snapshot with ports and starts a new empty journal.
+func (r *PostRepositoryFileDB) writeSnapshot(ports map[string]model.Port) error {
+	tmp, err := os.CreateTemp(r.dir, fileDBSnapshotName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	err = json.NewEncoder(writer).Encode(ports)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = tmp.Chmod(0o644)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), filepath.Join(r.dir, fileDBSnapshotName))
+	if err != nil {
+		return fmt.Errorf("replace snapshot: %w", err)
+	}
+
+	if r.journal != nil {
+		err = r.journal.Close()
+		if err != nil {
+			return fmt.Errorf("close journal: %w", err)
+		}
+	}
+
+	// everything in the journal is in the snapshot now, so it can be truncated
+	r.journal, err = os.OpenFile(filepath.Join(r.dir, fileDBJournalName), os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open journal: %w", err)
+	}
+	r.journalRecords = 0
+	r.journalSize = 0
+	r.journalErr = nil
+	return nil
+}
+
+func (r *PostRepositoryFileDB) loadSnapshot() error {
+	file, err := os.Open(filepath.Join(r.dir, fileDBSnapshotName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open snapshot: %w", err)
+	}
+	defer file.Close()
+
+	ports := map[string]model.Port{}
+	err = json.NewDecoder(bufio.NewReader(file)).Decode(&ports)
+	if err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+
+	for key, port := range ports {
+		err = r.PostRepositoryMemoryDB.Create(context.Background(), key, port)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PostRepositoryFileDB) replayJournal() error {
+	file, err := os.Open(filepath.Join(r.dir, fileDBJournalName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open journal: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for i := 1; ; i++ {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// a record without the trailing new line was not completely written before a crash,
+			// it has never been applied to the memory either, so it is dropped
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read journal: %w", err)
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var record journalRecord
+		err = json.Unmarshal(line, &record)
+		if err != nil {
+			return fmt.Errorf("decode journal record %d: %w", i, err)
+		}
+
+		err = r.replayRecord(record)
+		if err != nil {
+			return fmt.Errorf("journal record %d: %w", i, err)
+		}
+	}
+}
+
+func (r *PostRepositoryFileDB) replayRecord(record journalRecord) error {
+	switch record.Op {
+	case journalOpPut:
+		if record.Port == nil {
+			return errors.New("port is missing")
+		}
+		return r.PostRepositoryMemoryDB.Create(context.Background(), record.Key, *record.Port)
+	case journalOpDelete:
+		err := r.PostRepositoryMemoryDB.Delete(context.Background(), record.Key)
+		if err != nil && !errors.As(err, &ErrObjectNotFound{}) {
+			return err
+		}
+		return nil
+	case journalOpBatch:
+		for _, batchRecord := range record.Records {
+			err := r.replayRecord(batchRecord)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown operation %q", record.Op)
+	}
+}
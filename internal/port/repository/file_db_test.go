@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostRepositoryFileDB_Persistence(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	ajman := model.Port{Name: "Ajman", Country: "United Arab Emirates", Unlocs: []string{"AEAJM"}}
+	abuDhabi := model.Port{Name: "Abu Dhabi", Country: "United Arab Emirates", Unlocs: []string{"AEAUH"}}
+	dubai := model.Port{Name: "Dubai", Country: "United Arab Emirates", Unlocs: []string{"AEDXB"}}
+
+	rp, err := NewPostRepositoryFileDB(dir)
+	require.NoError(t, err)
+	require.NoError(t, rp.Create(ctx, "AEAJM", ajman))
+	require.NoError(t, rp.Create(ctx, "AEAUH", abuDhabi))
+	require.NoError(t, rp.Create(ctx, "AEDXB", dubai))
+	ajman.Name = "Ajman Port"
+	require.NoError(t, rp.Update(ctx, "AEAJM", ajman))
+	require.NoError(t, rp.Delete(ctx, "AEAUH"))
+	assert.ErrorAs(t, rp.Delete(ctx, "AEAUH"), &ErrObjectNotFound{})
+
+	// the journal is replayed when the repository was not closed, e.g. the process crashed
+	crash(t, rp)
+	crashed, err := NewPostRepositoryFileDB(dir)
+	require.NoError(t, err)
+	ports, err := crashed.ListAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]model.Port{"AEAJM": ajman, "AEDXB": dubai}, ports)
+
+	require.NoError(t, crashed.Close())
+
+	// a record which was not completely written must be dropped
+	journal, err := os.OpenFile(filepath.Join(dir, fileDBJournalName), os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = journal.WriteString(`{"op":"delete","key":"AEDXB"}` + "\n" + `{"op":"delete","key":"AEA`)
+	require.NoError(t, err)
+	require.NoError(t, journal.Close())
+
+	reopened, err := NewPostRepositoryFileDB(dir)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	ports, err = reopened.ListAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]model.Port{"AEAJM": ajman}, ports)
+
+	journalInfo, err := os.Stat(filepath.Join(dir, fileDBJournalName))
+	require.NoError(t, err)
+	assert.Zero(t, journalInfo.Size(), "journal must be compacted into the snapshot on open")
+}
+
+func TestPostRepositoryFileDB_CorruptedJournal(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, fileDBJournalName), []byte("not json\n"), 0o644))
+
+	_, err := NewPostRepositoryFileDB(dir)
+	assert.Error(t, err)
+}
+
+func TestPostRepositoryFileDB_Locked(t *testing.T) {
+	dir := t.TempDir()
+
+	rp, err := NewPostRepositoryFileDB(dir)
+	require.NoError(t, err)
+
+	_, err = NewPostRepositoryFileDB(dir)
+	assert.ErrorAs(t, err, &ErrRepositoryLocked{})
+
+	require.NoError(t, rp.Close())
+	reopened, err := NewPostRepositoryFileDB(dir)
+	require.NoError(t, err)
+	require.NoError(t, reopened.Close())
+}
+
+func TestPostRepositoryFileDB_FailedWrite(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	ajman := model.Port{Name: "Ajman", Country: "United Arab Emirates", Unlocs: []string{"AEAJM"}}
+	abuDhabi := model.Port{Name: "Abu Dhabi", Country: "United Arab Emirates", Unlocs: []string{"AEAUH"}}
+	dubai := model.Port{Name: "Dubai", Country: "United Arab Emirates", Unlocs: []string{"AEDXB"}}
+
+	rp, err := NewPostRepositoryFileDB(dir)
+	require.NoError(t, err)
+	require.NoError(t, rp.Create(ctx, "AEAJM", ajman))
+
+	// the disk fills up in the middle of a record, the part which was written is cut off
+	file := rp.journal.(*os.File)
+	rp.journal = &failingJournal{File: file}
+	assert.Error(t, rp.Create(ctx, "AEAUH", abuDhabi))
+	rp.journal = file
+	require.NoError(t, rp.Create(ctx, "AEDXB", dubai))
+
+	crash(t, rp)
+	reopened, err := NewPostRepositoryFileDB(dir)
+	require.NoError(t, err)
+	ports, err := reopened.ListAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]model.Port{"AEAJM": ajman, "AEDXB": dubai}, ports)
+
+	// the journal can not be cut back, it is not written anymore until it is compacted
+	file = reopened.journal.(*os.File)
+	reopened.journal = &failingJournal{File: file, truncateErr: errors.New("read-only file system")}
+	assert.Error(t, reopened.Create(ctx, "AEAUH", abuDhabi))
+	reopened.journal = file
+	assert.Error(t, reopened.Create(ctx, "AEAUH", abuDhabi))
+
+	require.NoError(t, reopened.compact())
+	require.NoError(t, reopened.Create(ctx, "AEAUH", abuDhabi))
+	require.NoError(t, reopened.Close())
+}
+
+// failingJournal writes the half of the records and fails.
+type failingJournal struct {
+	*os.File
+	truncateErr error
+}
+
+func (j *failingJournal) Write(p []byte) (int, error) {
+	n, _ := j.File.Write(p[:len(p)/2])
+	return n, errors.New("no space left on device")
+}
+
+func (j *failingJournal) Truncate(size int64) error {
+	if j.truncateErr != nil {
+		return j.truncateErr
+	}
+	return j.File.Truncate(size)
+}
+
+// crash releases the files of the repository without compacting its journal, like a process which has crashed.
+func crash(t *testing.T, rp *PostRepositoryFileDB) {
+	t.Helper()
+
+	require.NoError(t, rp.journal.Close())
+	require.NoError(t, rp.lock.Close())
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package repository
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockDir only opens the lock file, the directory can not be locked on this system.
+func lockDir(dir string) (*os.File, error) {
+	file, err := os.OpenFile(filepath.Join(dir, fileDBLockName), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+	return file, nil
+}
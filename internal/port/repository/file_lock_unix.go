@@ -0,0 +1,31 @@
+//go:build unix
+
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lockDir takes an exclusive lock of the directory, it is ErrRepositoryLocked when the directory is already locked.
+// The lock is released when the returned file is closed, or by the system when the process exits, so a crash
+// does not leave the directory locked.
+func lockDir(dir string) (*os.File, error) {
+	file, err := os.OpenFile(filepath.Join(dir, fileDBLockName), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrRepositoryLocked{Dir: dir}
+		}
+		return nil, fmt.Errorf("lock repository dir: %w", err)
+	}
+	return file, nil
+}
@@ -2,21 +2,43 @@ package repository
 
 import (
 	"context"
+	"strings"
 	"sync"
 
 	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/pkg/geo"
+	"github.com/fir1/port/pkg/search"
 )
 
 type PostRepositoryMemoryDB struct {
 	// Initialize your database connection here
 	storage map[string]model.Port
 	mu      sync.RWMutex
+
+	// secondary indexes map a lower-cased value to the keys of the ports which have it,
+	// they are only changed together with the storage under mu
+	byCountry  secondaryIndex
+	byTimezone secondaryIndex
+	byUnloc    secondaryIndex
+	byLocation *geo.Index
+	byText     *search.Index
+	byPrefix   *search.Trie
 }
 
 func NewPostRepositoryMemoryDB() PostRepositoryInterface {
+	return newPostRepositoryMemoryDB()
+}
+
+func newPostRepositoryMemoryDB() *PostRepositoryMemoryDB {
 	return &PostRepositoryMemoryDB{
-		storage: make(map[string]model.Port),
-		mu:      sync.RWMutex{},
+		storage:    make(map[string]model.Port),
+		mu:         sync.RWMutex{},
+		byCountry:  secondaryIndex{},
+		byTimezone: secondaryIndex{},
+		byUnloc:    secondaryIndex{},
+		byLocation: geo.NewIndex(geo.DefaultCellSizeDeg),
+		byText:     search.NewIndex(),
+		byPrefix:   search.NewTrie(),
 	}
 }
 
@@ -34,17 +56,247 @@ func (r *PostRepositoryMemoryDB) Get(ctx context.Context, key string) (model.Por
 func (r *PostRepositoryMemoryDB) Create(ctx context.Context, key string, entity model.Port) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.storage[key] = entity
+	r.put(key, entity)
 	return nil
 }
 
 func (r *PostRepositoryMemoryDB) Update(ctx context.Context, key string, entity model.Port) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.storage[key] = entity
+	r.put(key, entity)
+	return nil
+}
+
+func (r *PostRepositoryMemoryDB) Delete(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	port, found := r.storage[key]
+	if !found {
+		return ErrObjectNotFound{}
+	}
+	r.unindex(key, port)
+	delete(r.storage, key)
+	return nil
+}
+
+// Modify calls update under the write lock, so no other write happens in between.
+func (r *PostRepositoryMemoryDB) Modify(ctx context.Context, key string, update func(port model.Port, found bool) (model.Port, error)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	port, found := r.storage[key]
+	updated, err := update(port, found)
+	if err != nil {
+		return err
+	}
+	r.put(key, updated)
 	return nil
 }
 
+// Begin starts a transaction whose writes are applied under a single lock on commit.
+func (r *PostRepositoryMemoryDB) Begin(ctx context.Context) (Transaction, error) {
+	return newStagedTransaction(r), nil
+}
+
+func (r *PostRepositoryMemoryDB) apply(changes map[string]*model.Port) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, port := range changes {
+		if port != nil {
+			r.put(key, *port)
+			continue
+		}
+		if stored, found := r.storage[key]; found {
+			r.unindex(key, stored)
+			delete(r.storage, key)
+		}
+	}
+	return nil
+}
+
+// ListAll returns a copy of the storage, so the caller can range over it while other goroutines keep writing.
 func (r *PostRepositoryMemoryDB) ListAll(ctx context.Context) (map[string]model.Port, error) {
-	return r.storage, nil
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ports := make(map[string]model.Port, len(r.storage))
+	for key, port := range r.storage {
+		ports[key] = port
+	}
+	return ports, nil
+}
+
+func (r *PostRepositoryMemoryDB) ListPage(ctx context.Context, page model.PageRequest) (model.Page, error) {
+	return r.Find(ctx, model.PortFilter{}, page)
+}
+
+func (r *PostRepositoryMemoryDB) Find(ctx context.Context, filter model.PortFilter, page model.PageRequest) (model.Page, error) {
+	r.mu.RLock()
+	records := r.match(filter)
+	r.mu.RUnlock()
+
+	return paginate(records, page)
+}
+
+func (r *PostRepositoryMemoryDB) Nearby(ctx context.Context, location geo.Point, radiusKm float64, limit int) ([]model.NearbyPort, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	neighbours := r.byLocation.Nearby(location, radiusKm, limit)
+	ports := make([]model.NearbyPort, 0, len(neighbours))
+	for _, neighbour := range neighbours {
+		ports = append(ports, model.NearbyPort{
+			PortRecord: model.PortRecord{Unlocode: neighbour.ID, Port: r.storage[neighbour.ID]},
+			DistanceKm: neighbour.DistanceKm,
+		})
+	}
+	return ports, nil
+}
+
+func (r *PostRepositoryMemoryDB) Search(ctx context.Context, query string, limit int) ([]model.SearchResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matches := r.byText.Search(query, limit)
+	results := make([]model.SearchResult, 0, len(matches))
+	for _, match := range matches {
+		results = append(results, model.SearchResult{
+			PortRecord: model.PortRecord{Unlocode: match.ID, Port: r.storage[match.ID]},
+			Score:      match.Score,
+		})
+	}
+	return results, nil
+}
+
+func (r *PostRepositoryMemoryDB) Autocomplete(ctx context.Context, prefix string, limit int) ([]model.Suggestion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	completions := r.byPrefix.Complete(prefix, limit)
+	suggestions := make([]model.Suggestion, 0, len(completions))
+	for _, completion := range completions {
+		record := model.PortRecord{Unlocode: completion.ID, Port: r.storage[completion.ID]}
+		suggestions = append(suggestions, newSuggestion(record, completion))
+	}
+	return suggestions, nil
+}
+
+// put stores the port and keeps the secondary indexes up to date, r.mu must be locked for writing.
+func (r *PostRepositoryMemoryDB) put(key string, port model.Port) {
+	if old, found := r.storage[key]; found {
+		r.unindex(key, old)
+	}
+	r.storage[key] = port
+	r.index(key, port)
+}
+
+func (r *PostRepositoryMemoryDB) index(key string, port model.Port) {
+	r.byCountry.add(port.Country, key)
+	r.byTimezone.add(port.Timezone, key)
+	for _, unloc := range port.Unlocs {
+		r.byUnloc.add(unloc, key)
+	}
+	if lat, lon, ok := port.Location(); ok {
+		r.byLocation.Insert(key, geo.Point{Lat: lat, Lon: lon})
+	}
+	r.byText.Insert(key, searchFields(port)...)
+	r.byPrefix.Insert(key, autocompleteTerms(port)...)
+}
+
+func (r *PostRepositoryMemoryDB) unindex(key string, port model.Port) {
+	r.byCountry.remove(port.Country, key)
+	r.byTimezone.remove(port.Timezone, key)
+	for _, unloc := range port.Unlocs {
+		r.byUnloc.remove(unloc, key)
+	}
+	r.byLocation.Remove(key)
+	r.byText.Remove(key)
+	r.byPrefix.Remove(key)
+}
+
+// match returns the ports which match the filter, r.mu must be locked for reading.
+// The smallest of the secondary indexes which can be used for the filter gives the candidates,
+// so only the candidates are checked against the rest of the filter instead of every port.
+func (r *PostRepositoryMemoryDB) match(filter model.PortFilter) []model.PortRecord {
+	var candidates map[string]struct{}
+	for _, lookup := range []struct {
+		index secondaryIndex
+		value string
+	}{
+		{index: r.byCountry, value: filter.Country},
+		{index: r.byTimezone, value: filter.Timezone},
+		{index: r.byUnloc, value: filter.Unloc},
+	} {
+		if lookup.value == "" {
+			continue
+		}
+
+		keys := lookup.index.get(lookup.value)
+		if candidates == nil || len(keys) < len(candidates) {
+			candidates = keys
+		}
+		if len(candidates) == 0 {
+			return []model.PortRecord{}
+		}
+	}
+
+	if candidates == nil {
+		return r.matchScan(filter)
+	}
+
+	records := make([]model.PortRecord, 0, len(candidates))
+	for key := range candidates {
+		port := r.storage[key]
+		if filter.Matches(port) {
+			records = append(records, model.PortRecord{Unlocode: key, Port: port})
+		}
+	}
+	return records
+}
+
+// matchScan returns the ports which match the filter checking every port, r.mu must be locked for reading.
+func (r *PostRepositoryMemoryDB) matchScan(filter model.PortFilter) []model.PortRecord {
+	var records []model.PortRecord
+	for key, port := range r.storage {
+		if filter.Matches(port) {
+			records = append(records, model.PortRecord{Unlocode: key, Port: port})
+		}
+	}
+	return records
+}
+
+// secondaryIndex maps a case-insensitive value to the set of the port keys which have it.
+type secondaryIndex map[string]map[string]struct{}
+
+func (i secondaryIndex) add(value, key string) {
+	if value == "" {
+		return
+	}
+
+	value = strings.ToLower(value)
+	keys, found := i[value]
+	if !found {
+		keys = map[string]struct{}{}
+		i[value] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+func (i secondaryIndex) remove(value, key string) {
+	value = strings.ToLower(value)
+	keys, found := i[value]
+	if !found {
+		return
+	}
+
+	delete(keys, key)
+	if len(keys) == 0 {
+		delete(i, value)
+	}
+}
+
+func (i secondaryIndex) get(value string) map[string]struct{} {
+	return i[strings.ToLower(value)]
 }
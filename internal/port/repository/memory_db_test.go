@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostRepositoryMemoryDB_SecondaryIndexes(t *testing.T) {
+	ctx := context.Background()
+	rp := newPostRepositoryMemoryDB()
+
+	port := model.Port{Name: "Ajman", Country: "United Arab Emirates", Timezone: "Asia/Dubai", Unlocs: []string{"AEAJM", "AEXXX"}}
+	require.NoError(t, rp.Create(ctx, "AEAJM", port))
+
+	assert.Len(t, rp.byCountry.get("united arab emirates"), 1)
+	assert.Len(t, rp.byTimezone.get("ASIA/DUBAI"), 1)
+	assert.Len(t, rp.byUnloc.get("aexxx"), 1)
+
+	port.Country = "Oman"
+	port.Unlocs = []string{"AEAJM"}
+	require.NoError(t, rp.Update(ctx, "AEAJM", port))
+
+	assert.Empty(t, rp.byCountry.get("United Arab Emirates"), "old value must be removed from the index")
+	assert.Empty(t, rp.byUnloc.get("AEXXX"), "old value must be removed from the index")
+	assert.Len(t, rp.byCountry.get("Oman"), 1)
+
+	page, err := rp.Find(ctx, model.PortFilter{Country: "united arab emirates"}, model.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, page.Items)
+
+	page, err = rp.Find(ctx, model.PortFilter{Country: "oman", Unloc: "aeajm"}, model.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []model.PortRecord{{Unlocode: "AEAJM", Port: port}}, page.Items)
+
+	require.NoError(t, rp.Delete(ctx, "AEAJM"))
+	assert.Empty(t, rp.byCountry)
+	assert.Empty(t, rp.byTimezone)
+	assert.Empty(t, rp.byUnloc)
+}
+
+func BenchmarkPostRepositoryMemoryDB_Match(b *testing.B) {
+	ctx := context.Background()
+	rp := newPostRepositoryMemoryDB()
+	for key, port := range loadTestPorts(b) {
+		require.NoError(b, rp.Create(ctx, key, port))
+	}
+
+	filters := map[string]model.PortFilter{
+		"Country":  {Country: "Germany"},
+		"Timezone": {Timezone: "Asia/Dubai"},
+		"Unloc":    {Unloc: "AEAJM"},
+	}
+
+	for name, filter := range filters {
+		filter := filter
+		b.Run(name+"/Indexed", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				rp.mu.RLock()
+				_ = rp.match(filter)
+				rp.mu.RUnlock()
+			}
+		})
+		b.Run(name+"/Scan", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				rp.mu.RLock()
+				_ = rp.matchScan(filter)
+				rp.mu.RUnlock()
+			}
+		})
+	}
+}
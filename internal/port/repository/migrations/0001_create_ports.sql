@@ -0,0 +1,39 @@
+CREATE TABLE ports (
+    unlocode  TEXT PRIMARY KEY,
+    name      TEXT NOT NULL,
+    city      TEXT NOT NULL,
+    country   TEXT NOT NULL,
+    province  TEXT NOT NULL,
+    timezone  TEXT NOT NULL,
+    code      TEXT NOT NULL,
+    longitude REAL NULL,
+    latitude  REAL NULL
+);
+
+CREATE INDEX ports_country_idx ON ports (country);
+CREATE INDEX ports_province_idx ON ports (province);
+CREATE INDEX ports_timezone_idx ON ports (timezone);
+
+-- alias and regions have no fixed type in the model yet, so each value is stored as a JSON document
+CREATE TABLE port_aliases (
+    unlocode TEXT    NOT NULL REFERENCES ports (unlocode) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    alias    TEXT    NOT NULL,
+    PRIMARY KEY (unlocode, position)
+);
+
+CREATE TABLE port_regions (
+    unlocode TEXT    NOT NULL REFERENCES ports (unlocode) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    region   TEXT    NOT NULL,
+    PRIMARY KEY (unlocode, position)
+);
+
+CREATE TABLE port_unlocs (
+    unlocode TEXT    NOT NULL REFERENCES ports (unlocode) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    unloc    TEXT    NOT NULL,
+    PRIMARY KEY (unlocode, position)
+);
+
+CREATE INDEX port_unlocs_unloc_idx ON port_unlocs (unloc);
@@ -0,0 +1,4 @@
+-- ports are paginated by (sort column, unlocode), so the indexes cover both columns
+CREATE INDEX ports_name_unlocode_idx ON ports (name, unlocode);
+CREATE INDEX ports_code_unlocode_idx ON ports (code, unlocode);
+CREATE INDEX ports_country_unlocode_idx ON ports (country, unlocode);
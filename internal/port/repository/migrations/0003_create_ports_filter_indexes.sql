@@ -0,0 +1,5 @@
+-- ports are filtered case-insensitively, so the lower-cased values are indexed
+CREATE INDEX ports_country_lower_idx ON ports (LOWER(country));
+CREATE INDEX ports_province_lower_idx ON ports (LOWER(province));
+CREATE INDEX ports_city_lower_idx ON ports (LOWER(city));
+CREATE INDEX ports_timezone_lower_idx ON ports (LOWER(timezone));
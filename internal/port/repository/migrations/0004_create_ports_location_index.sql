@@ -0,0 +1,2 @@
+-- nearby ports are searched within a latitude/longitude bounding box
+CREATE INDEX ports_location_idx ON ports (latitude, longitude);
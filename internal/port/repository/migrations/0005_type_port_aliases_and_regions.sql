@@ -0,0 +1,46 @@
+-- alias and regions are typed in the model since the port version 2: the aliases are stored as plain text
+-- and every region has an ID and a name instead of a JSON document
+ALTER TABLE ports ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
+
+-- the legacy aliases are converted the same way as model.Port decodes them: the null ones are dropped, so are
+-- the booleans which the decoder rejects, and a number becomes its text, e.g. 1.0 becomes '1' and 2.5 becomes '2.5'
+DELETE FROM port_aliases WHERE json_valid(alias) AND json_type(alias) IN ('null', 'true', 'false');
+
+UPDATE port_aliases
+SET alias = CASE
+                WHEN json_type(alias) = 'real' AND json_extract(alias, '$') = CAST(json_extract(alias, '$') AS INTEGER)
+                    THEN CAST(CAST(json_extract(alias, '$') AS INTEGER) AS TEXT)
+                ELSE CAST(json_extract(alias, '$') AS TEXT)
+            END
+WHERE json_valid(alias);
+
+CREATE TABLE port_regions_typed (
+    unlocode  TEXT    NOT NULL REFERENCES ports (unlocode) ON DELETE CASCADE,
+    position  INTEGER NOT NULL,
+    region_id INTEGER NOT NULL,
+    name      TEXT    NOT NULL,
+    PRIMARY KEY (unlocode, position)
+);
+
+-- the legacy regions are converted the same way as model.Region decodes them
+INSERT INTO port_regions_typed (unlocode, position, region_id, name)
+SELECT unlocode,
+       position,
+       CASE
+           WHEN json_type(region) = 'integer' THEN json_extract(region, '$')
+           WHEN json_type(region) = 'text' AND CAST(json_extract(region, '$') AS INTEGER) || '' = json_extract(region, '$')
+               THEN CAST(json_extract(region, '$') AS INTEGER)
+           WHEN json_type(region) = 'object' THEN COALESCE(json_extract(region, '$.id'), 0)
+           ELSE 0
+       END,
+       CASE
+           WHEN json_type(region) = 'text' AND CAST(json_extract(region, '$') AS INTEGER) || '' != json_extract(region, '$')
+               THEN json_extract(region, '$')
+           WHEN json_type(region) = 'object' THEN COALESCE(json_extract(region, '$.name'), '')
+           ELSE ''
+       END
+FROM port_regions
+WHERE json_valid(region) AND json_type(region) != 'null';
+
+DROP TABLE port_regions;
+ALTER TABLE port_regions_typed RENAME TO port_regions;
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/pkg/geo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostRepository_Nearby(t *testing.T) {
+	ctx := context.Background()
+	ports := loadTestPorts(t)
+
+	testCases := []struct {
+		name     string
+		location geo.Point
+		radiusKm float64
+		limit    int
+	}{
+		{name: "Dubai", location: geo.Point{Lat: 25.27, Lon: 55.3}, radiusKm: 200, limit: 100},
+		{name: "DubaiLimit", location: geo.Point{Lat: 25.27, Lon: 55.3}, radiusKm: 200, limit: 3},
+		{name: "Antimeridian", location: geo.Point{Lat: -17.7, Lon: 179.9}, radiusKm: 1500, limit: 100},
+		{name: "Nothing", location: geo.Point{Lat: 0, Lon: -140}, radiusKm: 10, limit: 100},
+		{name: "Everything", location: geo.Point{Lat: 0, Lon: 0}, radiusKm: geo.MaxDistanceKm, limit: 0},
+	}
+
+	for name, rp := range newTestRepositories(t) {
+		rp := rp
+		t.Run(name, func(t *testing.T) {
+			for key, port := range ports {
+				require.NoError(t, rp.Create(ctx, key, port))
+			}
+
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					var expected []geo.Neighbour
+					for key, port := range ports {
+						lat, lon, ok := port.Location()
+						if !ok {
+							continue
+						}
+						point := geo.Point{Lat: lat, Lon: lon}
+						if distance := geo.Distance(tc.location, point); distance <= tc.radiusKm {
+							expected = append(expected, geo.Neighbour{ID: key, Point: point, DistanceKm: distance})
+						}
+					}
+					geo.SortNeighbours(expected)
+					if tc.limit > 0 && len(expected) > tc.limit {
+						expected = expected[:tc.limit]
+					}
+
+					nearby, err := rp.Nearby(ctx, tc.location, tc.radiusKm, tc.limit)
+					require.NoError(t, err)
+					require.Len(t, nearby, len(expected))
+					for i, port := range nearby {
+						assert.Equal(t, expected[i].ID, port.Unlocode)
+						assert.InDelta(t, expected[i].DistanceKm, port.DistanceKm, 1e-9)
+						assert.Equal(t, ports[port.Unlocode], port.Port)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestPostRepositoryMemoryDB_NearbyAfterUpdate(t *testing.T) {
+	ctx := context.Background()
+	rp := newPostRepositoryMemoryDB()
+
+	port := model.Port{Name: "Ajman", Coordinates: []float64{55.5136433, 25.4052165}}
+	require.NoError(t, rp.Create(ctx, "AEAJM", port))
+
+	port.Coordinates = nil
+	require.NoError(t, rp.Update(ctx, "AEAJM", port))
+
+	nearby, err := rp.Nearby(ctx, geo.Point{Lat: 25.4, Lon: 55.5}, 100, 10)
+	require.NoError(t, err)
+	assert.Empty(t, nearby, "port without coordinates must be removed from the spatial index")
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+
+	"github.com/fir1/port/internal/port/model"
+)
+
+// cursor points to the last port of a page. It is opaque to the clients, it keeps the sort of the page so a cursor
+// can not be used with a different sort, and the sort value so the next page starts right after the last port
+// even when it has been deleted in the meantime.
+type cursor struct {
+	Sort     string `json:"s"`
+	Value    string `json:"v"`
+	Unlocode string `json:"u"`
+}
+
+func encodeCursor(sort string, record model.PortRecord) string {
+	field, _ := model.PageRequest{Sort: sort}.SortField()
+	cursorBytes, _ := json.Marshal(cursor{Sort: sort, Value: sortValue(field, record), Unlocode: record.Unlocode})
+	return base64.RawURLEncoding.EncodeToString(cursorBytes)
+}
+
+func decodeCursor(page model.PageRequest) (cursor, error) {
+	cursorBytes, err := base64.RawURLEncoding.DecodeString(page.Cursor)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor{}
+	}
+
+	var c cursor
+	err = json.Unmarshal(cursorBytes, &c)
+	if err != nil || c.Sort != page.Sort {
+		return cursor{}, ErrInvalidCursor{}
+	}
+	return c, nil
+}
+
+func sortValue(field string, record model.PortRecord) string {
+	switch field {
+	case model.SortByCode:
+		return record.Code
+	case model.SortByName:
+		return record.Name
+	case model.SortByCountry:
+		return record.Country
+	default:
+		return record.Unlocode
+	}
+}
+
+// less reports whether the port with the value and unlocode comes before the other one.
+func less(value, unlocode, otherValue, otherUnlocode string, desc bool) bool {
+	if desc {
+		value, unlocode, otherValue, otherUnlocode = otherValue, otherUnlocode, value, unlocode
+	}
+	if value != otherValue {
+		return value < otherValue
+	}
+	return unlocode < otherUnlocode
+}
+
+// paginate sorts the records and cuts the requested page out of them.
+func paginate(records []model.PortRecord, page model.PageRequest) (model.Page, error) {
+	field, desc := page.SortField()
+	sort.Slice(records, func(i, j int) bool {
+		return less(sortValue(field, records[i]), records[i].Unlocode, sortValue(field, records[j]), records[j].Unlocode, desc)
+	})
+
+	start := page.Offset
+	if page.Cursor != "" {
+		c, err := decodeCursor(page)
+		if err != nil {
+			return model.Page{}, err
+		}
+
+		start = sort.Search(len(records), func(i int) bool {
+			return less(c.Value, c.Unlocode, sortValue(field, records[i]), records[i].Unlocode, desc)
+		})
+	}
+	if start > len(records) {
+		start = len(records)
+	}
+
+	end := start + page.Limit
+	if end > len(records) {
+		end = len(records)
+	}
+
+	result := model.Page{Items: records[start:end]}
+	if end < len(records) && end > start {
+		result.NextCursor = encodeCursor(page.Sort, records[end-1])
+	}
+	return result, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadTestPorts returns the ports from `data/ports.json`.
+func loadTestPorts(t testing.TB) map[string]model.Port {
+	t.Helper()
+
+	data, err := os.ReadFile("../../../data/ports.json")
+	require.NoError(t, err, "Failed to read file `/data/ports.json`")
+
+	ports := map[string]model.Port{}
+	require.NoError(t, json.Unmarshal(data, &ports))
+	return ports
+}
+
+func TestPostRepository_ListPage(t *testing.T) {
+	ctx := context.Background()
+	ports := loadTestPorts(t)
+
+	for name, rp := range newTestRepositories(t) {
+		rp := rp
+		t.Run(name, func(t *testing.T) {
+			for key, port := range ports {
+				require.NoError(t, rp.Create(ctx, key, port))
+			}
+
+			for _, sortBy := range []string{"unlocode", "code", "name", "-name", "country", "-country"} {
+				page := model.PageRequest{Limit: 100, Sort: sortBy}
+				field, desc := page.SortField()
+
+				// walk through all the pages with the cursor
+				var unlocodes []string
+				for {
+					result, err := rp.ListPage(ctx, page)
+					require.NoError(t, err)
+					require.LessOrEqual(t, len(result.Items), page.Limit)
+
+					for _, item := range result.Items {
+						unlocodes = append(unlocodes, item.Unlocode)
+					}
+					if result.NextCursor == "" {
+						break
+					}
+					page.Cursor = result.NextCursor
+				}
+
+				require.Len(t, unlocodes, len(ports), "every port must be returned exactly once when sorted by %s", sortBy)
+				assert.True(t, sort.SliceIsSorted(unlocodes, func(i, j int) bool {
+					return less(
+						sortValue(field, model.PortRecord{Unlocode: unlocodes[i], Port: ports[unlocodes[i]]}), unlocodes[i],
+						sortValue(field, model.PortRecord{Unlocode: unlocodes[j], Port: ports[unlocodes[j]]}), unlocodes[j],
+						desc,
+					)
+				}), "ports must be sorted by %s", sortBy)
+
+				offsetPage, err := rp.ListPage(ctx, model.PageRequest{Limit: 10, Offset: 250, Sort: sortBy})
+				require.NoError(t, err)
+				require.Len(t, offsetPage.Items, 10)
+				assert.Equal(t, unlocodes[250], offsetPage.Items[0].Unlocode)
+				assert.Equal(t, ports[unlocodes[250]], offsetPage.Items[0].Port)
+			}
+
+			_, err := rp.ListPage(ctx, model.PageRequest{Limit: 10, Cursor: "not a cursor"})
+			assert.ErrorAs(t, err, &ErrInvalidCursor{})
+
+			page, err := rp.ListPage(ctx, model.PageRequest{Limit: 10, Sort: "name"})
+			require.NoError(t, err)
+			_, err = rp.ListPage(ctx, model.PageRequest{Limit: 10, Sort: "country", Cursor: page.NextCursor})
+			assert.ErrorAs(t, err, &ErrInvalidCursor{}, "cursor must not be used with a different sort")
+
+			page, err = rp.ListPage(ctx, model.PageRequest{Limit: 10, Offset: len(ports)})
+			require.NoError(t, err)
+			assert.Empty(t, page.Items)
+			assert.Empty(t, page.NextCursor)
+		})
+	}
+}
+
+func TestPostRepository_Find(t *testing.T) {
+	ctx := context.Background()
+	ports := loadTestPorts(t)
+
+	testCases := []struct {
+		name          string
+		filter        model.PortFilter
+		expectedCount int
+	}{
+		{name: "Country", filter: model.PortFilter{Country: "germany"}, expectedCount: 31},
+		{name: "Timezone", filter: model.PortFilter{Timezone: "ASIA/DUBAI"}, expectedCount: 12},
+		{name: "Unloc", filter: model.PortFilter{Unloc: "cndlc"}, expectedCount: 2},
+		{name: "NamePrefix", filter: model.PortFilter{NamePrefix: "PORT"}, expectedCount: 46},
+		{name: "Combined", filter: model.PortFilter{NamePrefix: "port", Country: "Brazil"}, expectedCount: 3},
+		{name: "LikeWildcardIsNotSpecial", filter: model.PortFilter{NamePrefix: "%"}, expectedCount: 0},
+		{name: "NoMatch", filter: model.PortFilter{Country: "Atlantis"}, expectedCount: 0},
+	}
+
+	for name, rp := range newTestRepositories(t) {
+		rp := rp
+		t.Run(name, func(t *testing.T) {
+			for key, port := range ports {
+				require.NoError(t, rp.Create(ctx, key, port))
+			}
+
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					page := model.PageRequest{Limit: 5, Sort: "name"}
+
+					var records []model.PortRecord
+					for {
+						result, err := rp.Find(ctx, tc.filter, page)
+						require.NoError(t, err)
+
+						records = append(records, result.Items...)
+						if result.NextCursor == "" {
+							break
+						}
+						page.Cursor = result.NextCursor
+					}
+
+					assert.Len(t, records, tc.expectedCount)
+					for _, record := range records {
+						assert.Equal(t, ports[record.Unlocode], record.Port)
+						assert.True(t, tc.filter.Matches(record.Port), "port %s does not match the filter", record.Unlocode)
+					}
+				})
+			}
+		})
+	}
+}
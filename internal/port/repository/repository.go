@@ -4,11 +4,32 @@ import (
 	"context"
 
 	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/pkg/geo"
 )
 
 type PostRepositoryInterface interface {
 	Create(ctx context.Context, key string, entity model.Port) error
 	Update(ctx context.Context, key string, entity model.Port) error
 	Get(ctx context.Context, key string) (model.Port, error)
+	// Delete removes the port stored under the key, ErrObjectNotFound is returned if there is no such port.
+	Delete(ctx context.Context, key string) error
+	// Modify stores the port returned by update under the key, update is called with the stored port and whether
+	// there is one. No other write to the port happens in between, so a change made from the stored port is not lost
+	// to a concurrent write. The error of update is returned as is and nothing is written then.
+	Modify(ctx context.Context, key string, update func(port model.Port, found bool) (model.Port, error)) error
 	ListAll(ctx context.Context) (map[string]model.Port, error)
+	// ListPage returns a single page of ports in a stable order, ErrInvalidCursor is returned for a malformed cursor.
+	ListPage(ctx context.Context, page model.PageRequest) (model.Page, error)
+	// Find returns a single page of the ports which match the filter, in the same order as ListPage.
+	Find(ctx context.Context, filter model.PortFilter, page model.PageRequest) (model.Page, error)
+	// Nearby returns up to limit ports within the radius around the location, the closest port first.
+	Nearby(ctx context.Context, location geo.Point, radiusKm float64, limit int) ([]model.NearbyPort, error)
+	// Search returns up to limit ports whose name, city, alias or province match the full-text query, the best match first.
+	// The query tolerates typos and diacritics.
+	Search(ctx context.Context, query string, limit int) ([]model.SearchResult, error)
+	// Autocomplete returns up to limit ports whose name, city, alias or UN/LOCODE starts with the prefix,
+	// exact matches first, then UN/LOCODE, name, city and alias matches.
+	Autocomplete(ctx context.Context, prefix string, limit int) ([]model.Suggestion, error)
+	// Begin starts a transaction, its writes are not seen by the readers of the repository until it is committed.
+	Begin(ctx context.Context) (Transaction, error)
 }
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "modernc.org/sqlite"
+)
+
+// newTestRepositories returns every repository backend, so the same behaviour is checked for all of them.
+func newTestRepositories(t *testing.T) map[string]PostRepositoryInterface {
+	t.Helper()
+
+	fileDB, err := NewPostRepositoryFileDB(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, fileDB.Close())
+	})
+
+	return map[string]PostRepositoryInterface{
+		"memory": NewPostRepositoryMemoryDB(),
+		"file":   fileDB,
+		"sql":    newTestSQLRepository(t, t.TempDir()),
+	}
+}
+
+func newTestSQLRepository(t *testing.T, dir string) *PostRepositorySQL {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(dir, "ports.db")+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)")
+	require.NoError(t, err)
+
+	rp, err := NewPostRepositorySQL(context.Background(), db)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, rp.Close())
+	})
+	return rp
+}
+
+func TestPostRepository(t *testing.T) {
+	ajman := model.Port{
+		Name:        "Ajman",
+		City:        "Ajman",
+		Country:     "United Arab Emirates",
+		Alias:       []string{"Ajman Port"},
+		Regions:     []model.Region{{ID: 1}, {Name: "Gulf"}},
+		Coordinates: []float64{55.5136433, 25.4052165},
+		Province:    "Ajman",
+		Timezone:    "Asia/Dubai",
+		Unlocs:      []string{"AEAJM"},
+		Code:        "52000",
+	}
+	abuDhabi := model.Port{
+		Name:     "Abu Dhabi",
+		City:     "Abu Dhabi",
+		Country:  "United Arab Emirates",
+		Alias:    []string{},
+		Regions:  []model.Region{},
+		Province: "Abu Z¸aby [Abu Dhabi]",
+		Timezone: "Asia/Dubai",
+		Unlocs:   []string{"AEAUH", "AEABU"},
+		Code:     "52001",
+	}
+
+	for name, rp := range newTestRepositories(t) {
+		rp := rp
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			_, err := rp.Get(ctx, "AEAJM")
+			assert.ErrorAs(t, err, &ErrObjectNotFound{})
+
+			require.NoError(t, rp.Create(ctx, "AEAJM", ajman))
+			require.NoError(t, rp.Create(ctx, "AEAUH", abuDhabi))
+
+			port, err := rp.Get(ctx, "AEAJM")
+			require.NoError(t, err)
+			assert.Equal(t, ajman, port)
+
+			updated := ajman
+			updated.Name = "Ajman Port"
+			updated.Alias = []string{}
+			updated.Coordinates = nil
+			require.NoError(t, rp.Update(ctx, "AEAJM", updated))
+
+			port, err = rp.Get(ctx, "AEAJM")
+			require.NoError(t, err)
+			assert.Equal(t, updated, port)
+
+			ports, err := rp.ListAll(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]model.Port{"AEAJM": updated, "AEAUH": abuDhabi}, ports)
+
+			require.NoError(t, rp.Delete(ctx, "AEAJM"))
+			assert.ErrorAs(t, rp.Delete(ctx, "AEAJM"), &ErrObjectNotFound{})
+
+			_, err = rp.Get(ctx, "AEAJM")
+			assert.ErrorAs(t, err, &ErrObjectNotFound{})
+
+			ports, err = rp.ListAll(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]model.Port{"AEAUH": abuDhabi}, ports)
+		})
+	}
+}
+
+func TestPostRepository_Modify(t *testing.T) {
+	for name, rp := range newTestRepositories(t) {
+		rp := rp
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			err := rp.Modify(ctx, "AEAJM", func(port model.Port, found bool) (model.Port, error) {
+				assert.False(t, found)
+				return model.Port{Name: "Ajman", Alias: []string{}}, nil
+			})
+			require.NoError(t, err)
+
+			// every write sees the alias of the writes before it, so none of them is lost
+			const writers = 20
+			var wg sync.WaitGroup
+			for i := 0; i < writers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					err := rp.Modify(ctx, "AEAJM", func(port model.Port, found bool) (model.Port, error) {
+						assert.True(t, found)
+						port.Alias = append(port.Alias, fmt.Sprintf("alias %d", i))
+						return port, nil
+					})
+					assert.NoError(t, err)
+				}(i)
+			}
+			wg.Wait()
+
+			port, err := rp.Get(ctx, "AEAJM")
+			require.NoError(t, err)
+			assert.Len(t, port.Alias, writers)
+
+			errStop := errors.New("stop")
+			err = rp.Modify(ctx, "AEAJM", func(port model.Port, found bool) (model.Port, error) {
+				return model.Port{Name: "Changed"}, errStop
+			})
+			assert.Equal(t, errStop, err)
+
+			unchanged, err := rp.Get(ctx, "AEAJM")
+			require.NoError(t, err)
+			assert.Equal(t, port, unchanged)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/pkg/search"
+)
+
+// The weights make a match in the name rank above the same match in an alias, the city or the province.
+const (
+	searchNameWeight     = 1.0
+	searchAliasWeight    = 0.9
+	searchCityWeight     = 0.8
+	searchProvinceWeight = 0.6
+)
+
+// searchFields returns the fields of the port which are indexed for the full-text search.
+func searchFields(port model.Port) []search.Field {
+	fields := []search.Field{
+		{Text: port.Name, Weight: searchNameWeight},
+		{Text: port.City, Weight: searchCityWeight},
+		{Text: port.Province, Weight: searchProvinceWeight},
+	}
+	for _, alias := range port.Alias {
+		fields = append(fields, search.Field{Text: alias, Weight: searchAliasWeight})
+	}
+	return fields
+}
+
+// The autocomplete ranks of the texts of a port, an exact match comes first, then the lower rank.
+const (
+	autocompleteRankCode = iota
+	autocompleteRankName
+	autocompleteRankCity
+	autocompleteRankAlias
+)
+
+var autocompleteMatchTypes = map[int]string{
+	autocompleteRankCode:  model.MatchTypeCode,
+	autocompleteRankName:  model.MatchTypeName,
+	autocompleteRankCity:  model.MatchTypeCity,
+	autocompleteRankAlias: model.MatchTypeAlias,
+}
+
+// autocompleteTerms returns the texts of the port which are indexed for the autocomplete.
+func autocompleteTerms(port model.Port) []search.Term {
+	terms := []search.Term{
+		{Text: port.Name, Rank: autocompleteRankName},
+		{Text: port.City, Rank: autocompleteRankCity},
+	}
+	for _, unloc := range port.Unlocs {
+		terms = append(terms, search.Term{Text: unloc, Rank: autocompleteRankCode})
+	}
+	for _, alias := range port.Alias {
+		terms = append(terms, search.Term{Text: alias, Rank: autocompleteRankAlias})
+	}
+	return terms
+}
+
+func newSuggestion(record model.PortRecord, completion search.Completion) model.Suggestion {
+	return model.Suggestion{
+		PortRecord: record,
+		Match:      completion.Text,
+		MatchType:  autocompleteMatchTypes[completion.Rank],
+		Exact:      completion.Exact,
+	}
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostRepository_Search(t *testing.T) {
+	ctx := context.Background()
+	ports := loadTestPorts(t)
+
+	testCases := []struct {
+		query         string
+		expectedFirst string
+	}{
+		{query: "Abu Dhabi", expectedFirst: "AEAUH"},
+		{query: "abu dabi", expectedFirst: "AEAUH"},
+		{query: "Foochow", expectedFirst: "CNFOC"},
+		{query: "dubia", expectedFirst: "AEDXB"},
+	}
+
+	for name, rp := range newTestRepositories(t) {
+		rp := rp
+		t.Run(name, func(t *testing.T) {
+			for key, port := range ports {
+				require.NoError(t, rp.Create(ctx, key, port))
+			}
+
+			for _, tc := range testCases {
+				t.Run(tc.query, func(t *testing.T) {
+					results, err := rp.Search(ctx, tc.query, 5)
+					require.NoError(t, err)
+					require.NotEmpty(t, results)
+					assert.LessOrEqual(t, len(results), 5)
+					assert.Equal(t, tc.expectedFirst, results[0].Unlocode)
+					assert.Equal(t, ports[tc.expectedFirst], results[0].Port)
+					for i := 1; i < len(results); i++ {
+						assert.GreaterOrEqual(t, results[i-1].Score, results[i].Score)
+					}
+				})
+			}
+
+			// the index must follow the updates and the deletes
+			port := ports["CNFOC"]
+			port.Alias = []string{}
+			require.NoError(t, rp.Update(ctx, "CNFOC", port))
+			require.NoError(t, rp.Delete(ctx, "AEAUH"))
+
+			results, err := rp.Search(ctx, "foochow", 5)
+			require.NoError(t, err)
+			for _, result := range results {
+				assert.NotEqual(t, "CNFOC", result.Unlocode)
+			}
+
+			results, err = rp.Search(ctx, "abu dhabi", 5)
+			require.NoError(t, err)
+			for _, result := range results {
+				assert.NotEqual(t, "AEAUH", result.Unlocode)
+			}
+		})
+	}
+}
+
+func TestPostRepository_Autocomplete(t *testing.T) {
+	ctx := context.Background()
+	ports := loadTestPorts(t)
+
+	for name, rp := range newTestRepositories(t) {
+		rp := rp
+		t.Run(name, func(t *testing.T) {
+			for key, port := range ports {
+				require.NoError(t, rp.Create(ctx, key, port))
+			}
+
+			suggestions, err := rp.Autocomplete(ctx, "dubai", 5)
+			require.NoError(t, err)
+			require.NotEmpty(t, suggestions)
+			assert.Equal(t, "AEDXB", suggestions[0].Unlocode)
+			assert.True(t, suggestions[0].Exact)
+			assert.Equal(t, ports["AEDXB"], suggestions[0].Port)
+
+			suggestions, err = rp.Autocomplete(ctx, "aea", 5)
+			require.NoError(t, err)
+			require.NotEmpty(t, suggestions)
+			for _, suggestion := range suggestions {
+				assert.Equal(t, model.MatchTypeCode, suggestion.MatchType)
+				assert.True(t, strings.HasPrefix(suggestion.Unlocode, "AEA"), suggestion.Unlocode)
+			}
+
+			suggestions, err = rp.Autocomplete(ctx, "fooch", 5)
+			require.NoError(t, err)
+			require.Len(t, suggestions, 1)
+			assert.Equal(t, "CNFOC", suggestions[0].Unlocode)
+			assert.Equal(t, model.MatchTypeAlias, suggestions[0].MatchType)
+
+			// the trie must follow the deletes
+			require.NoError(t, rp.Delete(ctx, "CNFOC"))
+			suggestions, err = rp.Autocomplete(ctx, "fooch", 5)
+			require.NoError(t, err)
+			assert.Empty(t, suggestions)
+		})
+	}
+}
+
+func TestPostRepositorySQL_SearchAfterReopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	rp := newTestSQLRepository(t, dir)
+	ports := loadTestPorts(t)
+	require.NoError(t, rp.Create(ctx, "CNFOC", ports["CNFOC"]))
+
+	// the search index is built from the database when the repository is opened
+	reopened := newTestSQLRepository(t, dir)
+	results, err := reopened.Search(ctx, "foochow", 5)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "CNFOC", results[0].Unlocode)
+
+	suggestions, err := reopened.Autocomplete(ctx, "fooch", 5)
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1)
+	assert.Equal(t, "CNFOC", suggestions[0].Unlocode)
+}
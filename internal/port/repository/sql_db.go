@@ -0,0 +1,799 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/pkg/geo"
+	"github.com/fir1/port/pkg/search"
+)
+
+// PostRepositorySQL stores the ports in a relational database through database/sql. The queries are written
+// for SQLite, which is used by default through the pure-Go modernc.org/sqlite driver, so no external service
+// or cgo is required.
+//
+// The full-text search and the autocomplete indexes are kept in process, they are built from the database on start
+// and updated by the writes of this repository, so the writes made to the database by other processes are not
+// searchable until a restart.
+type PostRepositorySQL struct {
+	db *sql.DB
+
+	// indexMu is held for writing across the commit of a write and the index updates,
+	// so the indexes see the writes in the order of their commits
+	indexMu  sync.RWMutex
+	byText   *search.Index
+	byPrefix *search.Trie
+}
+
+// NewPostRepositorySQL migrates the database schema to the latest version and returns the repository.
+func NewPostRepositorySQL(ctx context.Context, db *sql.DB) (*PostRepositorySQL, error) {
+	err := migrate(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("migrate database: %w", err)
+	}
+
+	r := &PostRepositorySQL{db: db, byText: search.NewIndex(), byPrefix: search.NewTrie()}
+	ports, err := r.ListAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("build search indexes: %w", err)
+	}
+	for key, port := range ports {
+		r.byText.Insert(key, searchFields(port)...)
+		r.byPrefix.Insert(key, autocompleteTerms(port)...)
+	}
+	return r, nil
+}
+
+func (r *PostRepositorySQL) Get(ctx context.Context, key string) (model.Port, error) {
+	// the port and its child rows must be read from the same snapshot of the database
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return model.Port{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck // read only transaction has nothing to commit
+
+	return getPort(ctx, tx, key)
+}
+
+func (r *PostRepositorySQL) Create(ctx context.Context, key string, entity model.Port) error {
+	return r.put(ctx, key, entity)
+}
+
+func (r *PostRepositorySQL) Update(ctx context.Context, key string, entity model.Port) error {
+	return r.put(ctx, key, entity)
+}
+
+func (r *PostRepositorySQL) Delete(ctx context.Context, key string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // it is a no-op after commit
+
+	err = deletePort(ctx, tx, key)
+	if err != nil {
+		return err
+	}
+
+	r.indexMu.Lock()
+	defer r.indexMu.Unlock()
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	r.byText.Remove(key)
+	r.byPrefix.Remove(key)
+	return nil
+}
+
+// Modify reads the port and writes the updated one in a transaction which locks the port before it is read,
+// so a concurrent Modify of the port waits until this one is committed.
+func (r *PostRepositorySQL) Modify(ctx context.Context, key string, update func(port model.Port, found bool) (model.Port, error)) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // it is a no-op after commit
+
+	// a write takes the lock of the row, or of the whole database for SQLite, even when nothing changes
+	_, err = tx.ExecContext(ctx, `UPDATE ports SET unlocode = unlocode WHERE unlocode = ?`, key)
+	if err != nil {
+		return fmt.Errorf("lock port: %w", err)
+	}
+
+	port, err := getPort(ctx, tx, key)
+	found := err == nil
+	if err != nil && !errors.As(err, &ErrObjectNotFound{}) {
+		return err
+	}
+
+	updated, err := update(port, found)
+	if err != nil {
+		return err
+	}
+
+	err = putPort(ctx, tx, key, updated)
+	if err != nil {
+		return err
+	}
+
+	r.indexMu.Lock()
+	defer r.indexMu.Unlock()
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	r.byText.Insert(key, searchFields(updated)...)
+	r.byPrefix.Insert(key, autocompleteTerms(updated)...)
+	return nil
+}
+
+// Begin starts a database transaction, the search indexes are updated when it is committed.
+func (r *PostRepositorySQL) Begin(ctx context.Context) (Transaction, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlTransaction{repository: r, tx: tx, changes: map[string]*model.Port{}}, nil
+}
+
+func (r *PostRepositorySQL) ListAll(ctx context.Context) (map[string]model.Port, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck // read only transaction has nothing to commit
+
+	rows, err := tx.QueryContext(ctx, `SELECT unlocode, name, city, country, province, timezone, code, longitude, latitude, version
+FROM ports`)
+	if err != nil {
+		return nil, fmt.Errorf("select ports: %w", err)
+	}
+	defer rows.Close()
+
+	ports := map[string]*model.Port{}
+	for rows.Next() {
+		key, port, err := scanPort(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan port: %w", err)
+		}
+		ports[key] = &port
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("select ports: %w", err)
+	}
+
+	err = loadChildren(ctx, tx, ports, "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]model.Port, len(ports))
+	for key, port := range ports {
+		result[key] = *port
+	}
+	return result, nil
+}
+
+func (r *PostRepositorySQL) ListPage(ctx context.Context, page model.PageRequest) (model.Page, error) {
+	return r.Find(ctx, model.PortFilter{}, page)
+}
+
+func (r *PostRepositorySQL) Find(ctx context.Context, filter model.PortFilter, page model.PageRequest) (model.Page, error) {
+	field, desc := page.SortField()
+	column, found := sqlSortColumns[field]
+	if !found {
+		column = "unlocode"
+	}
+
+	comparison, order := ">", "ASC"
+	if desc {
+		comparison, order = "<", "DESC"
+	}
+
+	conditions, args := filterConditions(filter)
+	if page.Cursor != "" {
+		c, err := decodeCursor(page)
+		if err != nil {
+			return model.Page{}, err
+		}
+
+		//nolint:gosec // column is one of sqlSortColumns
+		conditions = append(conditions, fmt.Sprintf(`(%[1]s %[2]s ? OR (%[1]s = ? AND unlocode %[2]s ?))`, column, comparison))
+		args = append(args, c.Value, c.Value, c.Unlocode)
+	}
+
+	query := `SELECT unlocode, name, city, country, province, timezone, code, longitude, latitude, version FROM ports`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, ` AND `)
+	}
+
+	// one more port is selected to find out whether there is a next page
+	query += fmt.Sprintf(` ORDER BY %[1]s %[2]s, unlocode %[2]s LIMIT ?`, column, order)
+	args = append(args, page.Limit+1)
+	if page.Cursor == "" && page.Offset > 0 {
+		query += ` OFFSET ?`
+		args = append(args, page.Offset)
+	}
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return model.Page{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck // read only transaction has nothing to commit
+
+	records, err := selectPorts(ctx, tx, query, args...)
+	if err != nil {
+		return model.Page{}, err
+	}
+
+	result := model.Page{Items: records}
+	if len(records) > page.Limit {
+		result.Items = records[:page.Limit]
+		if page.Limit > 0 {
+			result.NextCursor = encodeCursor(page.Sort, result.Items[page.Limit-1])
+		}
+	}
+	return result, nil
+}
+
+func (r *PostRepositorySQL) Nearby(ctx context.Context, location geo.Point, radiusKm float64, limit int) ([]model.NearbyPort, error) {
+	box := geo.BoundingBoxAround(location, radiusKm)
+
+	query := `SELECT unlocode, name, city, country, province, timezone, code, longitude, latitude, version FROM ports
+WHERE latitude BETWEEN ? AND ?`
+	args := []interface{}{box.MinLat, box.MaxLat}
+	switch {
+	case box.AllLongitudes():
+		query += ` AND longitude IS NOT NULL`
+	case box.CrossesAntimeridian():
+		query += ` AND (longitude >= ? OR longitude <= ?)`
+		args = append(args, box.MinLon, box.MaxLon)
+	default:
+		query += ` AND longitude BETWEEN ? AND ?`
+		args = append(args, box.MinLon, box.MaxLon)
+	}
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck // read only transaction has nothing to commit
+
+	// the box contains the circle, the corners of the box are cut off by the exact distance
+	candidates, err := selectPorts(ctx, tx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(map[string]model.PortRecord, len(candidates))
+	var neighbours []geo.Neighbour
+	for _, candidate := range candidates {
+		lat, lon, ok := candidate.Location()
+		if !ok {
+			continue
+		}
+
+		point := geo.Point{Lat: lat, Lon: lon}
+		if distance := geo.Distance(location, point); distance <= radiusKm {
+			neighbours = append(neighbours, geo.Neighbour{ID: candidate.Unlocode, Point: point, DistanceKm: distance})
+			records[candidate.Unlocode] = candidate
+		}
+	}
+
+	geo.SortNeighbours(neighbours)
+	if limit > 0 && len(neighbours) > limit {
+		neighbours = neighbours[:limit]
+	}
+
+	ports := make([]model.NearbyPort, 0, len(neighbours))
+	for _, neighbour := range neighbours {
+		ports = append(ports, model.NearbyPort{PortRecord: records[neighbour.ID], DistanceKm: neighbour.DistanceKm})
+	}
+	return ports, nil
+}
+
+func (r *PostRepositorySQL) Search(ctx context.Context, query string, limit int) ([]model.SearchResult, error) {
+	r.indexMu.RLock()
+	matches := r.byText.Search(query, limit)
+	r.indexMu.RUnlock()
+
+	keys := make([]string, 0, len(matches))
+	for _, match := range matches {
+		keys = append(keys, match.ID)
+	}
+	records, err := r.selectByKeys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	// the ports are returned in the order of the matches, a port deleted after the search is skipped
+	results := make([]model.SearchResult, 0, len(matches))
+	for _, match := range matches {
+		if record, found := records[match.ID]; found {
+			results = append(results, model.SearchResult{PortRecord: record, Score: match.Score})
+		}
+	}
+	return results, nil
+}
+
+func (r *PostRepositorySQL) Autocomplete(ctx context.Context, prefix string, limit int) ([]model.Suggestion, error) {
+	r.indexMu.RLock()
+	completions := r.byPrefix.Complete(prefix, limit)
+	r.indexMu.RUnlock()
+
+	keys := make([]string, 0, len(completions))
+	for _, completion := range completions {
+		keys = append(keys, completion.ID)
+	}
+	records, err := r.selectByKeys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]model.Suggestion, 0, len(completions))
+	for _, completion := range completions {
+		if record, found := records[completion.ID]; found {
+			suggestions = append(suggestions, newSuggestion(record, completion))
+		}
+	}
+	return suggestions, nil
+}
+
+// selectByKeys returns the ports stored under the keys, the missing ports are left out.
+func (r *PostRepositorySQL) selectByKeys(ctx context.Context, keys []string) (map[string]model.PortRecord, error) {
+	if len(keys) == 0 {
+		return map[string]model.PortRecord{}, nil
+	}
+
+	placeholders := make([]string, 0, len(keys))
+	args := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		placeholders = append(placeholders, "?")
+		args = append(args, key)
+	}
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck // read only transaction has nothing to commit
+
+	records, err := selectPorts(ctx, tx, `SELECT unlocode, name, city, country, province, timezone, code, longitude, latitude, version
+FROM ports WHERE unlocode IN (`+strings.Join(placeholders, ", ")+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]model.PortRecord, len(records))
+	for _, record := range records {
+		byKey[record.Unlocode] = record
+	}
+	return byKey, nil
+}
+
+// Close closes the underlying database.
+func (r *PostRepositorySQL) Close() error {
+	return r.db.Close()
+}
+
+// put inserts the port or replaces the existing one together with all its child rows.
+func (r *PostRepositorySQL) put(ctx context.Context, key string, entity model.Port) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // it is a no-op after commit
+
+	err = putPort(ctx, tx, key, entity)
+	if err != nil {
+		return err
+	}
+
+	r.indexMu.Lock()
+	defer r.indexMu.Unlock()
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	r.byText.Insert(key, searchFields(entity)...)
+	r.byPrefix.Insert(key, autocompleteTerms(entity)...)
+	return nil
+}
+
+// putPort writes the port and its child rows in the transaction.
+func putPort(ctx context.Context, tx *sql.Tx, key string, entity model.Port) error {
+	var longitude, latitude sql.NullFloat64
+	switch len(entity.Coordinates) {
+	case 0:
+	case 2:
+		longitude = sql.NullFloat64{Float64: entity.Coordinates[0], Valid: true}
+		latitude = sql.NullFloat64{Float64: entity.Coordinates[1], Valid: true}
+	default:
+		return fmt.Errorf("port %s: coordinates must contain exactly two values [lon, lat]", key)
+	}
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO ports (unlocode, name, city, country, province, timezone, code, longitude, latitude, version)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (unlocode) DO UPDATE SET
+    name = excluded.name,
+    city = excluded.city,
+    country = excluded.country,
+    province = excluded.province,
+    timezone = excluded.timezone,
+    code = excluded.code,
+    longitude = excluded.longitude,
+    latitude = excluded.latitude,
+    version = excluded.version`,
+		key, entity.Name, entity.City, entity.Country, entity.Province, entity.Timezone, entity.Code, longitude, latitude, entity.Version)
+	if err != nil {
+		return fmt.Errorf("upsert port: %w", err)
+	}
+
+	err = deleteChildren(ctx, tx, key)
+	if err != nil {
+		return err
+	}
+
+	for i, alias := range entity.Alias {
+		_, err = tx.ExecContext(ctx, `INSERT INTO port_aliases (unlocode, position, alias) VALUES (?, ?, ?)`, key, i, alias)
+		if err != nil {
+			return fmt.Errorf("insert alias: %w", err)
+		}
+	}
+
+	for i, region := range entity.Regions {
+		_, err = tx.ExecContext(ctx, `INSERT INTO port_regions (unlocode, position, region_id, name) VALUES (?, ?, ?, ?)`,
+			key, i, region.ID, region.Name)
+		if err != nil {
+			return fmt.Errorf("insert region: %w", err)
+		}
+	}
+
+	for i, unloc := range entity.Unlocs {
+		_, err = tx.ExecContext(ctx, `INSERT INTO port_unlocs (unlocode, position, unloc) VALUES (?, ?, ?)`, key, i, unloc)
+		if err != nil {
+			return fmt.Errorf("insert unloc: %w", err)
+		}
+	}
+	return nil
+}
+
+// getPort reads the port and its child rows in the transaction.
+func getPort(ctx context.Context, tx *sql.Tx, key string) (model.Port, error) {
+	row := tx.QueryRowContext(ctx, `SELECT unlocode, name, city, country, province, timezone, code, longitude, latitude, version
+FROM ports WHERE unlocode = ?`, key)
+
+	_, port, err := scanPort(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Port{}, ErrObjectNotFound{}
+	}
+	if err != nil {
+		return model.Port{}, fmt.Errorf("select port: %w", err)
+	}
+
+	ports := map[string]*model.Port{key: &port}
+	err = loadChildren(ctx, tx, ports, `WHERE unlocode = ?`, key)
+	if err != nil {
+		return model.Port{}, err
+	}
+	return port, nil
+}
+
+// deletePort deletes the port and its child rows in the transaction, ErrObjectNotFound is returned
+// if there is no such port.
+func deletePort(ctx context.Context, tx *sql.Tx, key string) error {
+	err := deleteChildren(ctx, tx, key)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM ports WHERE unlocode = ?`, key)
+	if err != nil {
+		return fmt.Errorf("delete port: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrObjectNotFound{}
+	}
+	return nil
+}
+
+func deleteChildren(ctx context.Context, tx *sql.Tx, key string) error {
+	for _, table := range []string{"port_aliases", "port_regions", "port_unlocs"} {
+		_, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE unlocode = ?`, key) //nolint:gosec // table names are constants
+		if err != nil {
+			return fmt.Errorf("delete from %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// loadChildren fills alias, regions and unlocs of the ports from the child tables filtered by the where clause.
+func loadChildren(ctx context.Context, tx *sql.Tx, ports map[string]*model.Port, where string, args ...interface{}) error {
+	for _, port := range ports {
+		port.Alias = []string{}
+		port.Regions = []model.Region{}
+		port.Unlocs = []string{}
+	}
+
+	children := []struct {
+		table   string
+		columns string
+		// scan scans a row of the child table, it returns the key of the port and the function which adds the row to it
+		scan func(rows *sql.Rows) (string, func(port *model.Port), error)
+	}{
+		{
+			table:   "port_aliases",
+			columns: "alias",
+			scan: func(rows *sql.Rows) (string, func(port *model.Port), error) {
+				var key, alias string
+				err := rows.Scan(&key, &alias)
+				return key, func(port *model.Port) { port.Alias = append(port.Alias, alias) }, err
+			},
+		},
+		{
+			table:   "port_regions",
+			columns: "region_id, name",
+			scan: func(rows *sql.Rows) (string, func(port *model.Port), error) {
+				var (
+					key    string
+					region model.Region
+				)
+				err := rows.Scan(&key, &region.ID, &region.Name)
+				return key, func(port *model.Port) { port.Regions = append(port.Regions, region) }, err
+			},
+		},
+		{
+			table:   "port_unlocs",
+			columns: "unloc",
+			scan: func(rows *sql.Rows) (string, func(port *model.Port), error) {
+				var key, unloc string
+				err := rows.Scan(&key, &unloc)
+				return key, func(port *model.Port) { port.Unlocs = append(port.Unlocs, unloc) }, err
+			},
+		},
+	}
+
+	for _, child := range children {
+		//nolint:gosec // table and column names are constants
+		rows, err := tx.QueryContext(ctx, `SELECT unlocode, `+child.columns+` FROM `+child.table+` `+where+` ORDER BY unlocode, position`, args...)
+		if err != nil {
+			return fmt.Errorf("select %s: %w", child.table, err)
+		}
+
+		for rows.Next() {
+			key, add, err := child.scan(rows)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("scan %s: %w", child.table, err)
+			}
+
+			if port, found := ports[key]; found {
+				add(port)
+			}
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("select %s: %w", child.table, err)
+		}
+	}
+	return nil
+}
+
+// filterConditions returns the WHERE conditions of the filter together with their arguments.
+func filterConditions(filter model.PortFilter) ([]string, []interface{}) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	// the lower-cased columns are indexed, see the migrations
+	for _, field := range []struct {
+		column string
+		value  string
+	}{
+		{column: "country", value: filter.Country},
+		{column: "province", value: filter.Province},
+		{column: "city", value: filter.City},
+		{column: "timezone", value: filter.Timezone},
+	} {
+		if field.value != "" {
+			conditions = append(conditions, `LOWER(`+field.column+`) = LOWER(?)`)
+			args = append(args, field.value)
+		}
+	}
+
+	if filter.NamePrefix != "" {
+		conditions = append(conditions, `LOWER(name) LIKE LOWER(?) ESCAPE '\'`)
+		args = append(args, likeEscaper.Replace(filter.NamePrefix)+"%")
+	}
+
+	if filter.Unloc != "" {
+		conditions = append(conditions, `unlocode IN (SELECT unlocode FROM port_unlocs WHERE unloc = UPPER(?))`)
+		args = append(args, filter.Unloc)
+	}
+	return conditions, args
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+var sqlSortColumns = map[string]string{
+	model.SortByUnlocode: "unlocode",
+	model.SortByCode:     "code",
+	model.SortByName:     "name",
+	model.SortByCountry:  "country",
+}
+
+// selectPorts selects the ports with the query, and loads their child rows, the order of the ports is kept.
+func selectPorts(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]model.PortRecord, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select ports: %w", err)
+	}
+	defer rows.Close()
+
+	var records []model.PortRecord
+	for rows.Next() {
+		key, port, err := scanPort(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan port: %w", err)
+		}
+		records = append(records, model.PortRecord{Unlocode: key, Port: port})
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("select ports: %w", err)
+	}
+
+	if len(records) == 0 {
+		return []model.PortRecord{}, nil
+	}
+
+	ports := make(map[string]*model.Port, len(records))
+	placeholders := make([]string, 0, len(records))
+	keys := make([]interface{}, 0, len(records))
+	for i := range records {
+		ports[records[i].Unlocode] = &records[i].Port
+		placeholders = append(placeholders, "?")
+		keys = append(keys, records[i].Unlocode)
+	}
+
+	err = loadChildren(ctx, tx, ports, `WHERE unlocode IN (`+strings.Join(placeholders, ", ")+`)`, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPort(row rowScanner) (string, model.Port, error) {
+	var (
+		key                 string
+		port                model.Port
+		longitude, latitude sql.NullFloat64
+	)
+
+	err := row.Scan(&key, &port.Name, &port.City, &port.Country, &port.Province, &port.Timezone, &port.Code, &longitude, &latitude,
+		&port.Version)
+	if err != nil {
+		return "", model.Port{}, err
+	}
+
+	if longitude.Valid && latitude.Valid {
+		port.Coordinates = []float64{longitude.Float64, latitude.Float64}
+	}
+	return key, port, nil
+}
+
+// sqlTransaction writes the ports in a database transaction. The operations are serialized, as a write of a port
+// is made of several statements.
+type sqlTransaction struct {
+	repository *PostRepositorySQL
+
+	mu sync.Mutex
+	tx *sql.Tx
+	// changes are the ports written by the transaction, they are applied to the search indexes on commit,
+	// a nil port is deleted from them
+	changes map[string]*model.Port
+}
+
+func (t *sqlTransaction) Create(ctx context.Context, key string, entity model.Port) error {
+	return t.put(ctx, key, entity)
+}
+
+func (t *sqlTransaction) Update(ctx context.Context, key string, entity model.Port) error {
+	return t.put(ctx, key, entity)
+}
+
+func (t *sqlTransaction) Get(ctx context.Context, key string) (model.Port, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	port, err := getPort(ctx, t.tx, key)
+	return port, transactionErr(err)
+}
+
+func (t *sqlTransaction) Delete(ctx context.Context, key string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := deletePort(ctx, t.tx, key)
+	if err != nil {
+		return transactionErr(err)
+	}
+	t.changes[key] = nil
+	return nil
+}
+
+func (t *sqlTransaction) Commit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.repository.indexMu.Lock()
+	defer t.repository.indexMu.Unlock()
+
+	err := t.tx.Commit()
+	if err != nil {
+		return transactionErr(err)
+	}
+
+	for key, port := range t.changes {
+		if port == nil {
+			t.repository.byText.Remove(key)
+			t.repository.byPrefix.Remove(key)
+			continue
+		}
+		t.repository.byText.Insert(key, searchFields(*port)...)
+		t.repository.byPrefix.Insert(key, autocompleteTerms(*port)...)
+	}
+	return nil
+}
+
+func (t *sqlTransaction) Rollback() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// the transaction is also rolled back by database/sql when its context is cancelled
+	err := t.tx.Rollback()
+	if err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return err
+	}
+	return nil
+}
+
+func (t *sqlTransaction) put(ctx context.Context, key string, entity model.Port) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := putPort(ctx, t.tx, key, entity)
+	if err != nil {
+		return transactionErr(err)
+	}
+	t.changes[key] = &entity
+	return nil
+}
+
+// transactionErr reports the use of a finished database transaction like the other repositories do.
+func transactionErr(err error) error {
+	if errors.Is(err, sql.ErrTxDone) {
+		return ErrTransactionDone{}
+	}
+	return err
+}
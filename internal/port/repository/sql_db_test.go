@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostRepositorySQL_Migrations(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	port := model.Port{
+		Name:    "Ajman",
+		Country: "United Arab Emirates",
+		Alias:   []string{},
+		Regions: []model.Region{},
+		Unlocs:  []string{"AEAJM"},
+	}
+
+	rp := newTestSQLRepository(t, dir)
+	require.NoError(t, rp.Create(ctx, "AEAJM", port))
+
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+
+	// opening the same database again must not apply the migrations twice, nor lose any data
+	reopened := newTestSQLRepository(t, dir)
+
+	var applied, version int
+	err = reopened.db.QueryRowContext(ctx, `SELECT COUNT(*), MAX(version) FROM schema_migrations`).Scan(&applied, &version)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), applied)
+	assert.Equal(t, migrations[len(migrations)-1].version, version)
+
+	stored, err := reopened.Get(ctx, "AEAJM")
+	require.NoError(t, err)
+	assert.Equal(t, port, stored)
+}
+
+func TestPostRepositorySQL_InvalidCoordinates(t *testing.T) {
+	rp := newTestSQLRepository(t, t.TempDir())
+
+	err := rp.Create(context.Background(), "AEAJM", model.Port{Name: "Ajman", Coordinates: []float64{55.5}})
+	assert.Error(t, err)
+}
+
+func TestPostRepositorySQL_MigrateLegacyAliasesAndRegions(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "ports.db"))
+	require.NoError(t, err)
+
+	// the database is migrated to the version which stored alias and regions as JSON documents
+	_, err = db.ExecContext(ctx, `CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, applied_at TIMESTAMP NOT NULL)`)
+	require.NoError(t, err)
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	for _, m := range migrations {
+		if m.version > 4 {
+			break
+		}
+		require.NoError(t, applyMigration(ctx, db, m))
+	}
+
+	_, err = db.ExecContext(ctx, `INSERT INTO ports (unlocode, name, city, country, province, timezone, code)
+VALUES ('AEAJM', 'Ajman', 'Ajman', 'United Arab Emirates', 'Ajman', 'Asia/Dubai', '52000')`)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `INSERT INTO port_aliases (unlocode, position, alias)
+VALUES ('AEAJM', 0, '"test"'), ('AEAJM', 1, '42'), ('AEAJM', 2, 'null'), ('AEAJM', 3, 'true'), ('AEAJM', 4, '1.0'),
+       ('AEAJM', 5, '2.5')`)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `INSERT INTO port_regions (unlocode, position, region)
+VALUES ('AEAJM', 0, '1'), ('AEAJM', 1, '"2"'), ('AEAJM', 2, '"Gulf"'), ('AEAJM', 3, '{"id":4,"name":"Asia"}'), ('AEAJM', 4, 'null')`)
+	require.NoError(t, err)
+
+	rp, err := NewPostRepositorySQL(ctx, db)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, rp.Close())
+	})
+
+	port, err := rp.Get(ctx, "AEAJM")
+	require.NoError(t, err)
+	assert.Equal(t, 0, port.Version)
+	assert.Equal(t, []string{"test", "42", "1", "2.5"}, port.Alias)
+
+	// the migrated aliases are the ones of the legacy document decoded by the model, which rejects the booleans
+	var decoded model.Port
+	require.NoError(t, json.Unmarshal([]byte(`{"alias":["test",42,null,1.0,2.5]}`), &decoded))
+	assert.Equal(t, decoded.Alias, port.Alias)
+	assert.Equal(t, []model.Region{{ID: 1}, {ID: 2}, {Name: "Gulf"}, {ID: 4, Name: "Asia"}}, port.Regions)
+}
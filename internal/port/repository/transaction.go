@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/fir1/port/internal/port/model"
+)
+
+// Transaction is a unit of work over the ports. Its writes are only seen through the transaction until Commit
+// applies all of them at once, Rollback discards them. A transaction is safe for concurrent use, it can not be
+// used any more after Commit or Rollback.
+type Transaction interface {
+	Create(ctx context.Context, key string, entity model.Port) error
+	Update(ctx context.Context, key string, entity model.Port) error
+	// Get returns the port as it is seen by the transaction, with its own writes applied.
+	Get(ctx context.Context, key string) (model.Port, error)
+	// Delete removes the port stored under the key, ErrObjectNotFound is returned if there is no such port.
+	Delete(ctx context.Context, key string) error
+	// Commit applies the writes of the transaction, the readers of the repository see either all of them or none.
+	Commit() error
+	// Rollback discards the writes of the transaction, it is a no-op after Commit.
+	Rollback() error
+}
+
+// stagingTarget is a repository whose transactions are staged in memory and applied at once on commit.
+type stagingTarget interface {
+	Get(ctx context.Context, key string) (model.Port, error)
+	// apply applies the staged changes atomically, a nil port deletes the port.
+	apply(changes map[string]*model.Port) error
+}
+
+// stagedTransaction keeps the writes in memory until they are committed. The memory repositories keep all
+// the ports in memory anyway, so staging them does not change the memory needed by an import.
+type stagedTransaction struct {
+	target stagingTarget
+
+	mu      sync.Mutex
+	changes map[string]*model.Port
+	done    bool
+}
+
+func newStagedTransaction(target stagingTarget) *stagedTransaction {
+	return &stagedTransaction{target: target, changes: map[string]*model.Port{}}
+}
+
+func (t *stagedTransaction) Create(ctx context.Context, key string, entity model.Port) error {
+	return t.stage(key, &entity)
+}
+
+func (t *stagedTransaction) Update(ctx context.Context, key string, entity model.Port) error {
+	return t.stage(key, &entity)
+}
+
+func (t *stagedTransaction) Get(ctx context.Context, key string) (model.Port, error) {
+	t.mu.Lock()
+	if t.done {
+		t.mu.Unlock()
+		return model.Port{}, ErrTransactionDone{}
+	}
+	port, staged := t.changes[key]
+	t.mu.Unlock()
+
+	switch {
+	case !staged:
+		return t.target.Get(ctx, key)
+	case port == nil:
+		return model.Port{}, ErrObjectNotFound{}
+	default:
+		return *port, nil
+	}
+}
+
+func (t *stagedTransaction) Delete(ctx context.Context, key string) error {
+	_, err := t.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	return t.stage(key, nil)
+}
+
+func (t *stagedTransaction) Commit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return ErrTransactionDone{}
+	}
+	t.done = true
+	return t.target.apply(t.changes)
+}
+
+func (t *stagedTransaction) Rollback() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.done = true
+	t.changes = nil
+	return nil
+}
+
+func (t *stagedTransaction) stage(key string, port *model.Port) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return ErrTransactionDone{}
+	}
+	t.changes[key] = port
+	return nil
+}
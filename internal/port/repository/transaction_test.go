@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostRepository_Transaction(t *testing.T) {
+	ctx := context.Background()
+
+	// the empty slices are stored as they are read back by every backend
+	newPort := func(name, unlocode string) model.Port {
+		return model.Port{
+			Name:    name,
+			City:    name,
+			Country: "United Arab Emirates",
+			Alias:   []string{},
+			Regions: []model.Region{},
+			Unlocs:  []string{unlocode},
+		}
+	}
+	ajman := newPort("Ajman", "AEAJM")
+	abuDhabi := newPort("Abu Dhabi", "AEAUH")
+	dubai := newPort("Dubai", "AEDXB")
+
+	for name, rp := range newTestRepositories(t) {
+		rp := rp
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, rp.Create(ctx, "AEAJM", ajman))
+			require.NoError(t, rp.Create(ctx, "AEAUH", abuDhabi))
+
+			t.Run("Rollback", func(t *testing.T) {
+				tx, err := rp.Begin(ctx)
+				require.NoError(t, err)
+				require.NoError(t, tx.Create(ctx, "AEDXB", dubai))
+				require.NoError(t, tx.Delete(ctx, "AEAJM"))
+				require.NoError(t, tx.Rollback())
+
+				ports, err := rp.ListAll(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, map[string]model.Port{"AEAJM": ajman, "AEAUH": abuDhabi}, ports)
+
+				assert.ErrorAs(t, tx.Create(ctx, "AEDXB", dubai), &ErrTransactionDone{})
+			})
+
+			t.Run("Commit", func(t *testing.T) {
+				tx, err := rp.Begin(ctx)
+				require.NoError(t, err)
+
+				renamed := abuDhabi
+				renamed.Name = "Abu Dhabi Port"
+				require.NoError(t, tx.Create(ctx, "AEDXB", dubai))
+				require.NoError(t, tx.Update(ctx, "AEAUH", renamed))
+				require.NoError(t, tx.Delete(ctx, "AEAJM"))
+				assert.ErrorAs(t, tx.Delete(ctx, "AEAJM"), &ErrObjectNotFound{})
+
+				// the transaction sees its own writes
+				port, err := tx.Get(ctx, "AEDXB")
+				require.NoError(t, err)
+				assert.Equal(t, dubai, port)
+				_, err = tx.Get(ctx, "AEAJM")
+				assert.ErrorAs(t, err, &ErrObjectNotFound{})
+
+				// the readers of the repository do not see them until the commit
+				ports, err := rp.ListAll(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, map[string]model.Port{"AEAJM": ajman, "AEAUH": abuDhabi}, ports)
+
+				require.NoError(t, tx.Commit())
+				assert.ErrorAs(t, tx.Commit(), &ErrTransactionDone{})
+				assert.NoError(t, tx.Rollback(), "rollback after commit is a no-op")
+
+				ports, err = rp.ListAll(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, map[string]model.Port{"AEAUH": renamed, "AEDXB": dubai}, ports)
+
+				// the search indexes follow the commit
+				results, err := rp.Search(ctx, "dubai", 5)
+				require.NoError(t, err)
+				require.NotEmpty(t, results)
+				assert.Equal(t, "AEDXB", results[0].Unlocode)
+
+				suggestions, err := rp.Autocomplete(ctx, "ajm", 5)
+				require.NoError(t, err)
+				assert.Empty(t, suggestions)
+			})
+		})
+	}
+}
+
+func TestPostRepositoryFileDB_TransactionPersistence(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	ajman := model.Port{Name: "Ajman", Country: "United Arab Emirates", Unlocs: []string{"AEAJM"}}
+	dubai := model.Port{Name: "Dubai", Country: "United Arab Emirates", Unlocs: []string{"AEDXB"}}
+
+	rp, err := NewPostRepositoryFileDB(dir)
+	require.NoError(t, err)
+	require.NoError(t, rp.Create(ctx, "AEAJM", ajman))
+
+	tx, err := rp.Begin(ctx)
+	require.NoError(t, err)
+	require.NoError(t, tx.Create(ctx, "AEDXB", dubai))
+	require.NoError(t, tx.Delete(ctx, "AEAJM"))
+	require.NoError(t, tx.Commit())
+
+	// the committed transaction is replayed from the journal when the repository was not closed
+	crash(t, rp)
+	crashed, err := NewPostRepositoryFileDB(dir)
+	require.NoError(t, err)
+	defer crashed.Close()
+
+	ports, err := crashed.ListAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]model.Port{"AEDXB": dubai}, ports)
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/fir1/port/internal/port/model"
+)
+
+// The fields of a port which the columns of a CSV file can be mapped to. A port has either the latitude and
+// the longitude columns or a combined lat_lon column, e.g. "25.4052, 55.5136".
+const (
+	csvFieldUnlocode  = "unlocode"
+	csvFieldName      = "name"
+	csvFieldCity      = "city"
+	csvFieldCountry   = "country"
+	csvFieldAlias     = "alias"
+	csvFieldRegions   = "regions"
+	csvFieldProvince  = "province"
+	csvFieldTimezone  = "timezone"
+	csvFieldUnlocs    = "unlocs"
+	csvFieldCode      = "code"
+	csvFieldLatitude  = "latitude"
+	csvFieldLongitude = "longitude"
+	csvFieldLatLon    = "lat_lon"
+)
+
+var csvFields = []string{
+	csvFieldUnlocode, csvFieldName, csvFieldCity, csvFieldCountry, csvFieldAlias, csvFieldRegions, csvFieldProvince,
+	csvFieldTimezone, csvFieldUnlocs, csvFieldCode, csvFieldLatitude, csvFieldLongitude, csvFieldLatLon,
+}
+
+// DefaultCSVListSeparator separates the values of the multi-valued columns when no separator is configured.
+const DefaultCSVListSeparator = ";"
+
+// CSVDecoder decodes a CSV or a TSV file with a header row and a port on every other row, the fields may be quoted
+// like spreadsheets export them in both formats. The columns are mapped to the fields of the port by their header:
+// a header named like a field, e.g. "Name" or "unlocs", is mapped to it, Columns maps the other headers,
+// e.g. {"Port Name": "name"}. The headers are compared case-insensitively and the columns which are not mapped
+// are ignored. The record of a port is its row number, the header being row 1.
+type CSVDecoder struct {
+	// Comma is the field delimiter, ',' when it is zero.
+	Comma rune
+	// Columns maps the headers of the file to the fields of the port.
+	Columns map[string]string
+	// ListSeparator separates the values of the alias, regions and unlocs columns, DefaultCSVListSeparator
+	// when it is empty.
+	ListSeparator string
+}
+
+// NewCSVDecoder returns a decoder of the files delimited by comma, an unknown field in the columns is an error.
+func NewCSVDecoder(comma rune, columns map[string]string, listSeparator string) (CSVDecoder, error) {
+	for header, field := range columns {
+		if !isCSVField(field) {
+			return CSVDecoder{}, fmt.Errorf("column %q is mapped to unknown field %q, the fields are: %s",
+				header, field, strings.Join(csvFields, ", "))
+		}
+	}
+
+	if listSeparator == "" {
+		listSeparator = DefaultCSVListSeparator
+	}
+	return CSVDecoder{Comma: comma, Columns: columns, ListSeparator: listSeparator}, nil
+}
+
+func (d CSVDecoder) Decode(ctx context.Context, file io.Reader, emit func(Entry)) {
+	reader := csv.NewReader(file)
+	if d.Comma != 0 {
+		reader.Comma = d.Comma
+	}
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		emit(Entry{Error: fmt.Errorf("read header row: %w", err)})
+		return
+	}
+
+	columns, err := d.mapColumns(header)
+	if err != nil {
+		emit(Entry{Error: err})
+		return
+	}
+
+	for row := 2; ctx.Err() == nil; row++ {
+		values, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+
+		var parseErr *csv.ParseError
+		switch {
+		case err == nil:
+			emit(d.decodeRow(row, columns, values))
+		case errors.As(err, &parseErr):
+			// the reader goes on with the next row, so a malformed row is only an invalid record
+			emit(Entry{Record: row, Error: ErrInvalidRecord{Record: row, Unit: "row", Err: parseErr.Err}})
+		default:
+			emit(Entry{Error: fmt.Errorf("read row %d: %w", row, err)})
+			return
+		}
+	}
+}
+
+// mapColumns returns the field of every column of the header, an empty field for the columns which are not mapped.
+func (d CSVDecoder) mapColumns(header []string) ([]string, error) {
+	mapping := make(map[string]string, len(csvFields)+len(d.Columns))
+	for _, field := range csvFields {
+		mapping[field] = field
+	}
+	for name, field := range d.Columns {
+		mapping[strings.ToLower(strings.TrimSpace(name))] = field
+	}
+
+	columns := make([]string, len(header))
+	mapped := map[string]string{}
+	for i, name := range header {
+		if i == 0 {
+			// spreadsheets often write a byte order mark
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+
+		field := mapping[strings.ToLower(strings.TrimSpace(name))]
+		if field == "" {
+			continue
+		}
+		if other, found := mapped[field]; found {
+			return nil, fmt.Errorf("columns %q and %q are both mapped to the %s field", other, name, field)
+		}
+		mapped[field] = name
+		columns[i] = field
+	}
+
+	if _, found := mapped[csvFieldUnlocode]; !found {
+		return nil, fmt.Errorf("no column is mapped to the %s field", csvFieldUnlocode)
+	}
+	if _, found := mapped[csvFieldLatLon]; found {
+		_, lat := mapped[csvFieldLatitude]
+		_, lon := mapped[csvFieldLongitude]
+		if lat || lon {
+			return nil, fmt.Errorf("the %s column can not be combined with the %s and %s columns",
+				csvFieldLatLon, csvFieldLatitude, csvFieldLongitude)
+		}
+	}
+	return columns, nil
+}
+
+// decodeRow decodes the port of a row, a row which can not be decoded is returned with an ErrInvalidRecord.
+func (d CSVDecoder) decodeRow(row int, columns, values []string) Entry {
+	var (
+		code     string
+		lat, lon string
+		err      error
+	)
+	// the lists are empty rather than missing, like in the JSON files
+	port := model.Port{Alias: []string{}, Regions: []model.Region{}, Unlocs: []string{}}
+	for i, value := range values {
+		if i >= len(columns) {
+			break
+		}
+
+		value = strings.TrimSpace(value)
+		switch columns[i] {
+		case csvFieldUnlocode:
+			code = value
+		case csvFieldName:
+			port.Name = value
+		case csvFieldCity:
+			port.City = value
+		case csvFieldCountry:
+			port.Country = value
+		case csvFieldAlias:
+			port.Alias = d.splitList(value)
+		case csvFieldRegions:
+			port.Regions = regionsOf(d.splitList(value))
+		case csvFieldProvince:
+			port.Province = value
+		case csvFieldTimezone:
+			port.Timezone = value
+		case csvFieldUnlocs:
+			port.Unlocs = d.splitList(value)
+		case csvFieldCode:
+			port.Code = value
+		case csvFieldLatitude:
+			lat = value
+		case csvFieldLongitude:
+			lon = value
+		case csvFieldLatLon:
+			lat, lon, err = splitLatLon(value)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		port.Coordinates, err = csvCoordinates(lat, lon)
+	}
+	if err == nil {
+		err = port.Upgrade()
+	}
+	if err != nil {
+		return Entry{PortCode: code, Record: row, Error: ErrInvalidRecord{Record: row, Unit: "row", PortCode: code, Err: err}}
+	}
+	return Entry{Port: port, PortCode: code, Record: row}
+}
+
+// splitList splits a multi-valued column, the empty values are dropped.
+func (d CSVDecoder) splitList(value string) []string {
+	values := []string{}
+	for _, v := range strings.Split(value, d.ListSeparator) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// regionsOf converts the regions of a column like the ones of a legacy document, a number is a region ID
+// and anything else is a region name.
+func regionsOf(values []string) []model.Region {
+	regions := []model.Region{}
+	for _, v := range values {
+		if id, err := strconv.Atoi(v); err == nil {
+			regions = append(regions, model.Region{ID: id})
+		} else {
+			regions = append(regions, model.Region{Name: v})
+		}
+	}
+	return regions
+}
+
+// splitLatLon splits a combined "lat, lon" column, the values may also be separated by a space or a semicolon.
+func splitLatLon(value string) (lat, lon string, err error) {
+	if value == "" {
+		return "", "", nil
+	}
+
+	parts := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' '
+	})
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("column %s must hold the latitude and the longitude, got %q", csvFieldLatLon, value)
+	}
+	return parts[0], parts[1], nil
+}
+
+// csvCoordinates returns the [lon, lat] coordinates of the port, a port without both of them has no coordinates.
+func csvCoordinates(lat, lon string) ([]float64, error) {
+	if lat == "" && lon == "" {
+		return nil, nil
+	}
+	if lat == "" || lon == "" {
+		return nil, errors.New("the latitude and the longitude must be given together")
+	}
+
+	latitude, err := strconv.ParseFloat(lat, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latitude %q", lat)
+	}
+	longitude, err := strconv.ParseFloat(lon, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid longitude %q", lon)
+	}
+	return []float64{longitude, latitude}, nil
+}
+
+func isCSVField(field string) bool {
+	for _, f := range csvFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fir1/port/config"
+	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/internal/port/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeCSV(t *testing.T, decoder CSVDecoder, data string) []Entry {
+	t.Helper()
+
+	stream := NewStream(decoder)
+	go stream.Start(strings.NewReader(data))
+
+	var entries []Entry
+	for entry := range stream.Watch() {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestCSVDecoder(t *testing.T) {
+	decoder, err := NewCSVDecoder(',', map[string]string{"LOCODE": "unlocode", "Port Name": "name", "Position": "lat_lon"}, "|")
+	require.NoError(t, err)
+
+	data := "\ufeffLOCODE,Port Name,City,Country,Alias,Regions,Unlocs,Position,Notes\n" +
+		`AEAJM,Ajman,Ajman,United Arab Emirates,"Ajman Port| Ajman Harbour",3|Gulf,AEAJM|AEAJX,"25.4052165, 55.5136433",ignored` + "\n" +
+		`AEAUH,Abu Dhabi,Abu Dhabi,United Arab Emirates,,,AEAUH,not a position,` + "\n" +
+		`AEDXB,Dubai,Dubai` + "\n" +
+		`AEFJR,Fujairah,Fujairah,United Arab Emirates,,,AEFJR,,` + "\n"
+
+	entries := decodeCSV(t, decoder, data)
+	require.Len(t, entries, 4)
+
+	require.NoError(t, entries[0].Error)
+	assert.Equal(t, "AEAJM", entries[0].PortCode)
+	assert.Equal(t, 2, entries[0].Record, "the header is row 1")
+	assert.Equal(t, model.Port{
+		Version:     model.PortVersion,
+		Name:        "Ajman",
+		City:        "Ajman",
+		Country:     "United Arab Emirates",
+		Alias:       []string{"Ajman Port", "Ajman Harbour"},
+		Regions:     []model.Region{{ID: 3}, {Name: "Gulf"}},
+		Coordinates: []float64{55.5136433, 25.4052165},
+		Unlocs:      []string{"AEAJM", "AEAJX"},
+	}, entries[0].Port)
+
+	var invalidErr ErrInvalidRecord
+	require.ErrorAs(t, entries[1].Error, &invalidErr)
+	assert.Equal(t, 3, invalidErr.Record)
+	assert.Equal(t, "AEAUH", invalidErr.PortCode)
+	assert.Contains(t, invalidErr.Error(), "decode row 3: column lat_lon")
+
+	// a row with too few columns is invalid, the rows after it are still read
+	require.ErrorAs(t, entries[2].Error, &invalidErr)
+	assert.Equal(t, 4, entries[2].Record)
+	assert.Contains(t, invalidErr.Error(), "decode row 4: wrong number of fields")
+
+	require.NoError(t, entries[3].Error)
+	assert.Equal(t, "AEFJR", entries[3].PortCode)
+	assert.Nil(t, entries[3].Port.Coordinates)
+}
+
+func TestCSVDecoder_Header(t *testing.T) {
+	testCases := map[string]struct {
+		header   string
+		expected string
+	}{
+		"missing unlocode":      {"name,city", "no column is mapped to the unlocode field"},
+		"duplicated field":      {"unlocode,name,Name", `columns "name" and "Name" are both mapped to the name field`},
+		"lat_lon with latitude": {"unlocode,lat_lon,latitude", "can not be combined"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			entries := decodeCSV(t, CSVDecoder{}, tc.header+"\n")
+			require.Len(t, entries, 1)
+			require.Error(t, entries[0].Error)
+			assert.False(t, errors.As(entries[0].Error, &ErrInvalidRecord{}), "a header error ends the stream")
+			assert.Contains(t, entries[0].Error.Error(), tc.expected)
+		})
+	}
+
+	_, err := NewCSVDecoder(',', map[string]string{"Port": "port_name"}, "")
+	assert.ErrorContains(t, err, `unknown field "port_name"`)
+}
+
+func TestSavePortsFromFile_TSV(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/ports.tsv"
+	require.NoError(t, os.WriteFile(path, []byte("unlocode\tname\tcity\tcountry\tunlocs\tlatitude\tlongitude\n"+
+		"AEAJM\tAjman\tAjman\tUnited Arab Emirates\tAEAJM\t25.4052165\t55.5136433\n"+
+		"AEDXB\t\"\"\"Dubai\"\" Port\"\tDubai\tUnited Arab Emirates\tAEDXB\t\t\n"), 0o600))
+
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+	ctx := context.Background()
+
+	// the format is told by the extension of the file
+	report, err := portService.SavePortsFromFile(ctx, path, nil, ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+
+	port, err := portService.GetPort(ctx, "AEAJM")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{55.5136433, 25.4052165}, port.Coordinates)
+
+	port, err = portService.GetPort(ctx, "AEDXB")
+	require.NoError(t, err)
+	assert.Equal(t, `"Dubai" Port`, port.Name, "the fields of TSV files are quoted like the ones of CSV files")
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+	"strings"
+
+	"github.com/fir1/port/config"
+	"github.com/klauspost/compress/zstd"
+)
+
+// The content encodings of the streams which can be decompressed.
+const (
+	EncodingIdentity = "identity"
+	EncodingGzip     = "gzip"
+	EncodingZstd     = "zstd"
+)
+
+// zstdMaxWindow bounds the window of the zstd streams, so a stream can not make the decoder allocate more memory.
+// It is the window of the long distance matching of the zstd command, `zstd --long`.
+const zstdMaxWindow = 128 << 20
+
+// The magic numbers the compressed files start with.
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// compressionExtensions are the extensions of the compressed files, the format is told by the extension before them.
+var compressionExtensions = []string{".gz", ".gzip", ".zst", ".zstd"}
+
+// importReader returns the reader of the ports of the file and the decoder of their format. The compression is told
+// by the first bytes of the file: a gzip or a zstd stream is decompressed as it is read and the members of a zip
+// archive are read one by one, so the memory used does not grow with the size of the file. The bytes read from
+// the file itself are counted by the progress, the members of an archive which are not port files are not read.
+// The returned function releases the decompressor.
+func (s PortService) importReader(file multipart.File, format ImportFormat, progress *ImportProgress) (io.Reader, Decoder, func(), error) {
+	magic := make([]byte, len(zstdMagic))
+	n, err := file.ReadAt(magic, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, nil, fmt.Errorf("read file header: %w", err)
+	}
+	magic = magic[:n]
+
+	if bytes.HasPrefix(magic, zipMagic) {
+		size, err := file.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("get archive size: %w", err)
+		}
+		readerAt := &progressReaderAt{reader: file}
+		archive, err := zip.NewReader(readerAt, size)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("open zip archive: %w", err)
+		}
+		// the directory at the end of the archive is searched with overlapping reads, only the members are counted
+		readerAt.progress = progress
+		return nil, zipDecoder{archive: archive, config: s.config}, func() {}, nil
+	}
+
+	decoder, err := format.decoder(s.config)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	reader, release, err := decodeContent(progress.reader(file), encodingOf(magic))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return reader, decoder, release, nil
+}
+
+// encodingOf tells the encoding of a stream by its first bytes, it is empty for a stream which is not compressed.
+func encodingOf(magic []byte) string {
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return EncodingGzip
+	case bytes.HasPrefix(magic, zstdMagic):
+		return EncodingZstd
+	default:
+		return ""
+	}
+}
+
+// sniffEncoding tells the encoding of a stream by its first bytes without consuming them, a zip archive
+// can not be read as a stream, so it is ErrUnsupportedEncoding.
+func sniffEncoding(stream *bufio.Reader) (string, error) {
+	magic, err := stream.Peek(len(zstdMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("read stream header: %w", err)
+	}
+	if bytes.HasPrefix(magic, zipMagic) {
+		return "", ErrUnsupportedEncoding{Encoding: "zip"}
+	}
+	return encodingOf(magic), nil
+}
+
+// decodeContent returns the reader which decompresses the stream with the encoding, the stream is returned as it is
+// for the empty and the identity encodings. The returned function releases the decompressor.
+func decodeContent(stream io.Reader, encoding string) (io.Reader, func(), error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", EncodingIdentity:
+		return stream, func() {}, nil
+	case EncodingGzip, "x-gzip":
+		gz, err := gzip.NewReader(stream)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open gzip stream: %w", err)
+		}
+		return gz, func() { gz.Close() }, nil
+	case EncodingZstd:
+		// the stream is decoded as fast as the ports are imported, more goroutines would only decode further ahead
+		zr, err := zstd.NewReader(stream, zstd.WithDecoderConcurrency(1), zstd.WithDecoderLowmem(true),
+			zstd.WithDecoderMaxWindow(zstdMaxWindow))
+		if err != nil {
+			return nil, nil, fmt.Errorf("open zstd stream: %w", err)
+		}
+		return zr, zr.Close, nil
+	default:
+		return nil, nil, ErrUnsupportedEncoding{Encoding: encoding}
+	}
+}
+
+// zipDecoder decodes the port files of a zip archive one after the other, each of them in the format told by
+// its extension. The other members, like a README, are skipped. The archive is read through its own reader,
+// so the file passed to Decode is not used.
+type zipDecoder struct {
+	archive *zip.Reader
+	config  config.Config
+}
+
+func (d zipDecoder) Decode(ctx context.Context, _ io.Reader, emit func(Entry)) {
+	var found bool
+	for _, member := range d.archive.File {
+		if ctx.Err() != nil {
+			return
+		}
+
+		format, ok := memberFormat(member)
+		if !ok {
+			continue
+		}
+		found = true
+
+		if !d.decodeMember(ctx, member, format, emit) {
+			return
+		}
+	}
+
+	if !found {
+		emit(Entry{Error: errors.New("the zip archive has no .json, .ndjson, .jsonl, .csv or .tsv member")})
+	}
+}
+
+// decodeMember decodes the ports of a member, it returns false when the member has ended the stream with an error.
+func (d zipDecoder) decodeMember(ctx context.Context, member *zip.File, format ImportFormat, emit func(Entry)) bool {
+	decoder, err := format.decoder(d.config)
+	if err != nil {
+		emit(Entry{Error: fmt.Errorf("zip member %s: %w", member.Name, err)})
+		return false
+	}
+
+	file, err := member.Open()
+	if err != nil {
+		emit(Entry{Error: fmt.Errorf("zip member %s: %w", member.Name, err)})
+		return false
+	}
+	defer file.Close()
+
+	ok := true
+	decoder.Decode(ctx, file, func(entry Entry) {
+		entry.File = member.Name
+		if entry.Error != nil && !errors.As(entry.Error, &ErrInvalidRecord{}) {
+			entry.Error = fmt.Errorf("zip member %s: %w", member.Name, entry.Error)
+			ok = false
+		}
+		emit(entry)
+	})
+	return ok
+}
+
+// memberFormat returns the format of a member of a zip archive, it is false for the members which are not port files.
+func memberFormat(member *zip.File) (ImportFormat, bool) {
+	// the archives made on macOS hold the resource forks of the files in the __MACOSX folder
+	if member.FileInfo().IsDir() || strings.HasPrefix(member.Name, "__MACOSX/") {
+		return "", false
+	}
+
+	format, found := importFormatsByExtension[strings.ToLower(path.Ext(member.Name))]
+	return format, found
+}
+
+// trimCompressionExtension removes the extension of the compression from the name of a file, e.g. ports.json.gz
+// is ports.json.
+func trimCompressionExtension(name string) string {
+	for _, ext := range compressionExtensions {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
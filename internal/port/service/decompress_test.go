@@ -0,0 +1,179 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fir1/port/config"
+	"github.com/fir1/port/internal/port/repository"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipData(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func zstdData(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	require.NoError(t, err)
+	_, err = zw.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func zipData(t *testing.T, members map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	// the members are written in a fixed order, so the records of the report can be checked
+	for _, name := range []string{"README.md", "__MACOSX/ports/._ports.json", "ports/ports.json", "ports/more.csv"} {
+		data, found := members[name]
+		if !found {
+			continue
+		}
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestSavePortsFromFile_Compressed(t *testing.T) {
+	const (
+		jsonPorts = `{
+  "AEAJM": {"name": "Ajman", "city": "Ajman", "country": "United Arab Emirates", "unlocs": ["AEAJM"]},
+  "AEAUH": {"name": "Abu Dhabi", "city": "Abu Dhabi", "country": "United Arab Emirates", "unlocs": ["AEAUH"]}
+}`
+		ndjsonPorts = `{"code": "AEAJM", "port": {"name": "Ajman", "city": "Ajman", "country": "United Arab Emirates", "unlocs": ["AEAJM"]}}
+{"code": "AEAUH", "port": {"name": "Abu Dhabi", "city": "Abu Dhabi", "country": "United Arab Emirates", "unlocs": ["AEAUH"]}}
+`
+		csvPorts = "unlocode,name,city,country,unlocs\nAEDXB,Dubai,Dubai,United Arab Emirates,AEDXB\nAEFJR,Fujairah,Fujairah,United Arab Emirates,AEAJM\n"
+	)
+
+	testCases := map[string]struct {
+		name    string
+		data    []byte
+		created int
+	}{
+		"gzip":             {"ports.json.gz", gzipData(t, jsonPorts), 2},
+		"zstd":             {"ports.ndjson.zst", zstdData(t, ndjsonPorts), 2},
+		"told by contents": {"ports.json", gzipData(t, jsonPorts), 2},
+		"zip": {"ports.zip", zipData(t, map[string]string{
+			"README.md":                   "not a port file",
+			"__MACOSX/ports/._ports.json": "not a port file either",
+			"ports/ports.json":            jsonPorts,
+			"ports/more.csv":              csvPorts,
+		}), 3},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.name)
+			require.NoError(t, os.WriteFile(path, tc.data, 0o600))
+
+			portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+			progress := &ImportProgress{}
+			report, err := portService.SavePortsFromFile(context.Background(), path, nil,
+				ImportOptions{Mode: ImportModeLenient, Progress: progress})
+			require.NoError(t, err)
+			assert.Equal(t, tc.created, report.Created)
+			assert.LessOrEqual(t, progress.Bytes(), int64(len(tc.data)), "the compressed bytes are counted")
+
+			if name == "zip" {
+				// the invalid port of the CSV member is reported with the member and its row
+				require.Len(t, report.Errors, 1)
+				assert.Equal(t, "ports/more.csv", report.Errors[0].File)
+				assert.Equal(t, 3, report.Errors[0].Record)
+			}
+		})
+	}
+}
+
+func TestSavePortsFromFile_CompressedErrors(t *testing.T) {
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+
+	truncated := gzipData(t, `{"AEAJM": {"name": "Ajman", "city": "Ajman", "country": "United Arab Emirates", "unlocs": ["AEAJM"]}}`)
+	testCases := map[string]struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		"truncated gzip": {"ports.json.gz", truncated[:len(truncated)/2], "unexpected EOF"},
+		"empty zip":      {"ports.zip", zipData(t, map[string]string{"README.md": "no ports"}), "the zip archive has no"},
+		"broken member":  {"ports.zip", zipData(t, map[string]string{"ports/ports.json": `["AEAJM"]`}), "zip member ports/ports.json: expected '{'"},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.name)
+			require.NoError(t, os.WriteFile(path, tc.data, 0o600))
+
+			_, err := portService.SavePortsFromFile(context.Background(), path, nil, ImportOptions{Mode: ImportModeLenient})
+			assert.ErrorContains(t, err, tc.expected)
+		})
+	}
+}
+
+func TestSavePortsFromStream(t *testing.T) {
+	const ndjsonPorts = `{"code": "AEAJM", "port": {"name": "Ajman", "city": "Ajman", "country": "United Arab Emirates", "unlocs": ["AEAJM"]}}
+{"code": "AEAUH", "port": {"name": "Abu Dhabi", "city": "Abu Dhabi", "country": "United Arab Emirates", "unlocs": ["AEAUH"]}}
+`
+	testCases := map[string]struct {
+		encoding string
+		data     []byte
+	}{
+		"identity": {"", []byte(ndjsonPorts)},
+		"gzip":     {"gzip", gzipData(t, ndjsonPorts)},
+		"x-gzip":   {"x-gzip", gzipData(t, ndjsonPorts)},
+		"zstd":     {"ZSTD", zstdData(t, ndjsonPorts)},
+		// a stream without the encoding is told by its first bytes
+		"gzip sniffed": {"", gzipData(t, ndjsonPorts)},
+		"zstd sniffed": {"", zstdData(t, ndjsonPorts)},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+			progress := &ImportProgress{}
+
+			// the stream is only read, it is neither seeked nor read at an offset
+			stream := struct{ io.Reader }{bytes.NewReader(tc.data)}
+			report, err := portService.SavePortsFromStream(context.Background(), stream, tc.encoding,
+				ImportOptions{Format: ImportFormatNDJSON, Progress: progress})
+			require.NoError(t, err)
+			assert.Equal(t, 2, report.Created)
+			assert.Equal(t, int64(len(tc.data)), progress.Bytes())
+		})
+	}
+
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+	stream := &bytes.Buffer{}
+	_, err := portService.SavePortsFromStream(context.Background(), stream, "br", ImportOptions{})
+	assert.ErrorAs(t, err, &ErrUnsupportedEncoding{})
+
+	archive := bytes.NewReader(zipData(t, map[string]string{"ports/ports.json": "{}"}))
+	_, err = portService.SavePortsFromStream(context.Background(), archive, "", ImportOptions{})
+	assert.ErrorAs(t, err, &ErrUnsupportedEncoding{})
+
+	_, err = portService.SavePortsFromStream(context.Background(), stream, "", ImportOptions{Mode: "partial"})
+	assert.ErrorAs(t, err, &ErrInvalidQuery{})
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/internal/port/repository"
+	"github.com/fir1/port/pkg/geo"
+)
+
+// MaxDistanceMatrixPorts is the maximum number of ports in a distance matrix.
+const MaxDistanceMatrixPorts = 100
+
+// PortDistance returns the great-circle distance and the initial bearing from one port to another.
+func (s PortService) PortDistance(ctx context.Context, from, to string) (model.PortDistance, error) {
+	from, to = NormalizeUnlocode(from), NormalizeUnlocode(to)
+
+	points, err := s.portLocations(ctx, []string{from, to})
+	if err != nil {
+		return model.PortDistance{}, err
+	}
+
+	distance := geo.Distance(points[0], points[1])
+	return model.PortDistance{
+		From:           from,
+		To:             to,
+		DistanceKm:     distance,
+		DistanceNm:     geo.KmToNauticalMiles(distance),
+		InitialBearing: geo.InitialBearing(points[0], points[1]),
+	}, nil
+}
+
+// DistanceMatrix returns the great-circle distances between every pair of the ports.
+func (s PortService) DistanceMatrix(ctx context.Context, unlocodes []string) (model.DistanceMatrix, error) {
+	normalized := make([]string, 0, len(unlocodes))
+	for _, unlocode := range unlocodes {
+		if unlocode = NormalizeUnlocode(unlocode); unlocode != "" {
+			normalized = append(normalized, unlocode)
+		}
+	}
+
+	if len(normalized) == 0 || len(normalized) > MaxDistanceMatrixPorts {
+		return model.DistanceMatrix{}, ErrInvalidQuery{
+			Reasons: []string{fmt.Sprintf("between 1 and %d unlocodes must be provided", MaxDistanceMatrixPorts)},
+		}
+	}
+
+	points, err := s.portLocations(ctx, normalized)
+	if err != nil {
+		return model.DistanceMatrix{}, err
+	}
+
+	matrix := model.DistanceMatrix{
+		Unlocodes:   normalized,
+		DistancesKm: make([][]float64, len(points)),
+		DistancesNm: make([][]float64, len(points)),
+	}
+	for i := range points {
+		matrix.DistancesKm[i] = make([]float64, len(points))
+		matrix.DistancesNm[i] = make([]float64, len(points))
+		for j := range points {
+			if j < i {
+				// the distance is symmetric
+				matrix.DistancesKm[i][j] = matrix.DistancesKm[j][i]
+			} else if j > i {
+				matrix.DistancesKm[i][j] = geo.Distance(points[i], points[j])
+			}
+			matrix.DistancesNm[i][j] = geo.KmToNauticalMiles(matrix.DistancesKm[i][j])
+		}
+	}
+	return matrix, nil
+}
+
+// portLocations returns the locations of the ports in the same order, ErrMissingCoordinates lists all the ports
+// without valid coordinates.
+func (s PortService) portLocations(ctx context.Context, unlocodes []string) ([]geo.Point, error) {
+	points := make([]geo.Point, 0, len(unlocodes))
+	var missing []string
+	for _, unlocode := range unlocodes {
+		port, err := s.repository.Get(ctx, unlocode)
+		if errors.As(err, &repository.ErrObjectNotFound{}) {
+			return nil, ErrPortNotFound{Unlocode: unlocode, Err: err}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		lat, lon, ok := port.Location()
+		if !ok {
+			if !containsString(missing, unlocode) {
+				missing = append(missing, unlocode)
+			}
+			continue
+		}
+		points = append(points, geo.Point{Lat: lat, Lon: lon})
+	}
+
+	if len(missing) > 0 {
+		return nil, ErrMissingCoordinates{Unlocodes: missing}
+	}
+	return points, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
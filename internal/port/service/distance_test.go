@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fir1/port/config"
+	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/internal/port/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortService_PortDistance(t *testing.T) {
+	ctx := context.Background()
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+
+	ports := map[string]model.Port{
+		"AEAJM": {Name: "Ajman", Coordinates: []float64{55.5136433, 25.4052165}, Unlocs: []string{"AEAJM"}},
+		"AEDXB": {Name: "Dubai", Coordinates: []float64{55.27, 25.25}, Unlocs: []string{"AEDXB"}},
+		"AEXXX": {Name: "Nowhere", Unlocs: []string{"AEXXX"}},
+	}
+	for unlocode, port := range ports {
+		require.NoError(t, portService.repository.Create(ctx, unlocode, port))
+	}
+
+	distance, err := portService.PortDistance(ctx, "aeajm", "AEDXB")
+	require.NoError(t, err)
+	assert.Equal(t, "AEAJM", distance.From)
+	assert.Equal(t, "AEDXB", distance.To)
+	assert.InDelta(t, 29.96, distance.DistanceKm, 0.01)
+	assert.InDelta(t, distance.DistanceKm/1.852, distance.DistanceNm, 1e-9)
+	assert.InDelta(t, 234.9, distance.InitialBearing, 0.1)
+
+	_, err = portService.PortDistance(ctx, "AEAJM", "AEXXX")
+	assert.Equal(t, ErrMissingCoordinates{Unlocodes: []string{"AEXXX"}}, err)
+
+	_, err = portService.PortDistance(ctx, "AEAJM", "ZZZZZ")
+	assert.ErrorAs(t, err, &ErrPortNotFound{})
+	assert.ErrorAs(t, err, &repository.ErrObjectNotFound{})
+
+	matrix, err := portService.DistanceMatrix(ctx, []string{"AEAJM", " aedxb", "AEAJM"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AEAJM", "AEDXB", "AEAJM"}, matrix.Unlocodes)
+	require.Len(t, matrix.DistancesKm, 3)
+	for i := range matrix.DistancesKm {
+		assert.Zero(t, matrix.DistancesKm[i][i])
+		for j := range matrix.DistancesKm[i] {
+			assert.Equal(t, matrix.DistancesKm[i][j], matrix.DistancesKm[j][i])
+		}
+	}
+	assert.Equal(t, distance.DistanceKm, matrix.DistancesKm[0][1])
+	assert.Zero(t, matrix.DistancesKm[0][2])
+
+	_, err = portService.DistanceMatrix(ctx, []string{""})
+	assert.ErrorAs(t, err, &ErrInvalidQuery{})
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidPort is returned when the port document provided by the client can not be decoded or applied,
+// the ports which are decoded but break the rules are reported with validation.ValidationError.
+type ErrInvalidPort struct {
+	Reasons []string
+}
+
+func (e ErrInvalidPort) Error() string {
+	return "invalid port: " + strings.Join(e.Reasons, "; ")
+}
+
+// ErrInvalidQuery is returned when the ports can not be listed with the query provided by the client.
+type ErrInvalidQuery struct {
+	Reasons []string
+}
+
+func (e ErrInvalidQuery) Error() string {
+	return "invalid query: " + strings.Join(e.Reasons, "; ")
+}
+
+// ErrMissingCoordinates is returned when a distance can not be calculated because the ports have no coordinates.
+type ErrMissingCoordinates struct {
+	Unlocodes []string
+}
+
+func (e ErrMissingCoordinates) Error() string {
+	return "ports have no valid coordinates: " + strings.Join(e.Unlocodes, ", ")
+}
+
+// ErrPortNotFound is returned when one of several requested ports does not exist, it wraps
+// repository.ErrObjectNotFound.
+type ErrPortNotFound struct {
+	Unlocode string
+	Err      error
+}
+
+func (e ErrPortNotFound) Error() string {
+	return "port " + e.Unlocode + " not found"
+}
+
+func (e ErrPortNotFound) Unwrap() error {
+	return e.Err
+}
+
+// ErrInvalidRecord is returned for a record of an imported file which can not be decoded into a port,
+// the rest of the file can still be read.
+type ErrInvalidRecord struct {
+	Record int
+	// Unit is what the record is called in the message, "line" when it is empty.
+	Unit     string
+	PortCode string
+	Err      error
+}
+
+func (e ErrInvalidRecord) Error() string {
+	unit := e.Unit
+	if unit == "" {
+		unit = "line"
+	}
+	return fmt.Sprintf("decode %s %d: %v", unit, e.Record, e.Err)
+}
+
+func (e ErrInvalidRecord) Unwrap() error {
+	return e.Err
+}
+
+// ErrUnsupportedEncoding is returned when an imported stream is compressed with an encoding which can not be decoded.
+type ErrUnsupportedEncoding struct {
+	Encoding string
+}
+
+func (e ErrUnsupportedEncoding) Error() string {
+	return fmt.Sprintf("unsupported content encoding %q, the encodings are: identity, gzip, zstd", e.Encoding)
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fir1/port/internal/port/model"
+)
+
+// GetPort returns a single port by its UN/LOCODE. The code is matched case-insensitively,
+// repository.ErrObjectNotFound is returned when there is no such port.
+func (s PortService) GetPort(ctx context.Context, unlocode string) (model.Port, error) {
+	return s.repository.Get(ctx, NormalizeUnlocode(unlocode))
+}
+
+// NormalizeUnlocode returns the UN/LOCODE in the form the ports are stored under, anything keyed by a port
+// should use it so the same port is not found under two keys.
+func NormalizeUnlocode(unlocode string) string {
+	return strings.ToUpper(strings.TrimSpace(unlocode))
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/internal/port/repository"
+)
+
+// DiffAction tells what an import would do to a port.
+type DiffAction string
+
+const (
+	DiffActionAdded   DiffAction = "added"
+	DiffActionChanged DiffAction = "changed"
+	// DiffActionRemoved is the action of the ports which are deleted by a sync because they are missing from the file.
+	DiffActionRemoved DiffAction = "removed"
+)
+
+// FieldChangeType tells how a field of a port would change.
+type FieldChangeType string
+
+const (
+	FieldAdded   FieldChangeType = "added"
+	FieldRemoved FieldChangeType = "removed"
+	FieldChanged FieldChangeType = "changed"
+)
+
+// PortDiff is the change an import would make to a port, the unchanged ports have no diff.
+type PortDiff struct {
+	// Record is the position of the port in the file, starting from 1. It is 0 for the removed ports.
+	Record int `json:"record,omitempty"`
+	// File is the archive member of the record, it is empty unless an archive has been imported.
+	File     string        `json:"file,omitempty"`
+	Unlocode string        `json:"unlocode"`
+	Action   DiffAction    `json:"action"`
+	Changes  []FieldChange `json:"changes"`
+}
+
+// FieldChange is the change of a single field, Old is missing for the added fields and New for the removed ones.
+type FieldChange struct {
+	Field string          `json:"field"`
+	Type  FieldChangeType `json:"type"`
+	Old   interface{}     `json:"old,omitempty"`
+	New   interface{}     `json:"new,omitempty"`
+}
+
+// diffPorts returns the changes of the fields from the stored port to the imported one, in the order of the fields
+// of model.Port. The version is not compared as the ports are always upgraded before they are stored.
+func diffPorts(stored, imported model.Port) []FieldChange {
+	oldValue := reflect.ValueOf(normalizePort(stored))
+	newValue := reflect.ValueOf(normalizePort(imported))
+	portType := oldValue.Type()
+
+	changes := []FieldChange{}
+	for i := 0; i < portType.NumField(); i++ {
+		field := strings.Split(portType.Field(i).Tag.Get("json"), ",")[0]
+		if field == "version" {
+			continue
+		}
+
+		oldField, newField := oldValue.Field(i), newValue.Field(i)
+		if reflect.DeepEqual(oldField.Interface(), newField.Interface()) {
+			continue
+		}
+
+		change := FieldChange{Field: field, Type: FieldChanged, Old: oldField.Interface(), New: newField.Interface()}
+		switch {
+		case oldField.IsZero():
+			change.Type, change.Old = FieldAdded, nil
+		case newField.IsZero():
+			change.Type, change.New = FieldRemoved, nil
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// previewPorts is the dry run of an import, the ports which a sync would delete are listed after the ports of the file.
+func (s PortService) previewPorts(ctx context.Context, file io.Reader, decoder Decoder, mode ImportMode, opts ImportOptions) (ImportReport, error) {
+	report := newReportBuilder(mode, true)
+	report.report.Sync = opts.Sync
+
+	var seen *keySet
+	if opts.Sync {
+		seen = &keySet{keys: map[string]struct{}{}}
+	}
+
+	err := s.streamPorts(ctx, file, decoder, mode, report, opts.Progress, func(ctx context.Context, entry Entry) error {
+		seen.add(entry.PortCode)
+		return s.previewPort(ctx, entry, report)
+	})
+	if err != nil || !opts.Sync {
+		return report.finish(), err
+	}
+
+	missing, err := s.missingPorts(ctx, seen)
+	if err != nil {
+		return report.finish(), err
+	}
+	for _, record := range missing {
+		report.add(outcomeDeleted)
+		report.diff(PortDiff{Unlocode: record.Unlocode, Action: DiffActionRemoved, Changes: diffPorts(record.Port, model.Port{})})
+	}
+	return report.finish(), nil
+}
+
+// previewPort compares the port with the stored one like importPort, but it only adds the outcome and the diff
+// to the report without writing the port.
+func (s PortService) previewPort(ctx context.Context, entry Entry, report *reportBuilder) error {
+	stored, err := s.repository.Get(ctx, entry.PortCode)
+	switch {
+	case err == nil:
+		changes := diffPorts(stored, entry.Port)
+		if len(changes) == 0 {
+			report.add(outcomeUnchanged)
+			return nil
+		}
+		report.add(outcomeUpdated)
+		report.diff(PortDiff{Record: entry.Record, File: entry.File, Unlocode: entry.PortCode, Action: DiffActionChanged, Changes: changes})
+		return nil
+	case errors.As(err, &repository.ErrObjectNotFound{}):
+		report.add(outcomeCreated)
+		report.diff(PortDiff{Record: entry.Record, File: entry.File, Unlocode: entry.PortCode, Action: DiffActionAdded, Changes: diffPorts(model.Port{}, entry.Port)})
+		return nil
+	default:
+		return fmt.Errorf("error getting port with ID %s: %w", entry.PortCode, err)
+	}
+}
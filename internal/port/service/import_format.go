@@ -0,0 +1,92 @@
+package service
+
+import (
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/fir1/port/config"
+)
+
+// ImportFormat is the format of an imported file.
+type ImportFormat string
+
+const (
+	// ImportFormatJSON is a single JSON object which holds the ports keyed by their UN/LOCODE.
+	ImportFormatJSON ImportFormat = "json"
+	// ImportFormatNDJSON is newline-delimited JSON with a `{"code": ..., "port": {...}}` object on every line.
+	ImportFormatNDJSON ImportFormat = "ndjson"
+	// ImportFormatCSV is comma-separated values with a header row, see CSVDecoder.
+	ImportFormatCSV ImportFormat = "csv"
+	// ImportFormatTSV is tab-separated values with a header row, see CSVDecoder.
+	ImportFormatTSV ImportFormat = "tsv"
+)
+
+// importFormatsByMediaType are the media types of the formats, with the common aliases of NDJSON.
+var importFormatsByMediaType = map[string]ImportFormat{
+	"application/json":          ImportFormatJSON,
+	"text/json":                 ImportFormatJSON,
+	"application/x-ndjson":      ImportFormatNDJSON,
+	"application/ndjson":        ImportFormatNDJSON,
+	"application/jsonl":         ImportFormatNDJSON,
+	"application/x-jsonl":       ImportFormatNDJSON,
+	"application/jsonlines":     ImportFormatNDJSON,
+	"application/x-jsonlines":   ImportFormatNDJSON,
+	"text/csv":                  ImportFormatCSV,
+	"application/csv":           ImportFormatCSV,
+	"text/tab-separated-values": ImportFormatTSV,
+}
+
+// importFormatsByExtension are the file extensions of the formats.
+var importFormatsByExtension = map[string]ImportFormat{
+	".json":   ImportFormatJSON,
+	".ndjson": ImportFormatNDJSON,
+	".jsonl":  ImportFormatNDJSON,
+	".csv":    ImportFormatCSV,
+	".tsv":    ImportFormatTSV,
+	".tab":    ImportFormatTSV,
+}
+
+// DetectImportFormat tells the format of a file by its content type, or by the extension of its name when
+// the content type is missing or does not tell the format, like application/octet-stream or application/gzip.
+// The extension of a compressed file is the one before the compression, e.g. ports.csv.gz is a CSV file.
+// It is ImportFormatJSON when neither of them tells the format.
+func DetectImportFormat(contentType, fileName string) ImportFormat {
+	if format, found := MediaTypeFormat(contentType); found {
+		return format
+	}
+
+	if format, found := importFormatsByExtension[strings.ToLower(filepath.Ext(trimCompressionExtension(fileName)))]; found {
+		return format
+	}
+	return ImportFormatJSON
+}
+
+// MediaTypeFormat returns the format of the content type, it is false when the content type is not
+// the media type of a format.
+func MediaTypeFormat(contentType string) (ImportFormat, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+
+	format, found := importFormatsByMediaType[mediaType]
+	return format, found
+}
+
+// decoder returns the decoder of the format, the columns of the CSV and TSV files are mapped by the config.
+// It is ErrInvalidQuery for an unknown format.
+func (f ImportFormat) decoder(cnf config.Config) (Decoder, error) {
+	switch f {
+	case "", ImportFormatJSON:
+		return JSONDecoder{}, nil
+	case ImportFormatNDJSON:
+		return NDJSONDecoder{}, nil
+	case ImportFormatCSV:
+		return NewCSVDecoder(',', cnf.ImportCSVColumns, cnf.ImportCSVListSeparator)
+	case ImportFormatTSV:
+		return NewCSVDecoder('\t', cnf.ImportCSVColumns, cnf.ImportCSVListSeparator)
+	default:
+		return nil, ErrInvalidQuery{Reasons: []string{"format must be one of: json, ndjson, csv, tsv"}}
+	}
+}
@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fir1/port/config"
+)
+
+// ImportJobState is the state of an import job.
+type ImportJobState string
+
+const (
+	ImportJobQueued    ImportJobState = "queued"
+	ImportJobRunning   ImportJobState = "running"
+	ImportJobSucceeded ImportJobState = "succeeded"
+	ImportJobFailed    ImportJobState = "failed"
+	ImportJobCancelled ImportJobState = "cancelled"
+)
+
+// finished tells whether the job will not change any more.
+func (s ImportJobState) finished() bool {
+	return s == ImportJobSucceeded || s == ImportJobFailed || s == ImportJobCancelled
+}
+
+// ImportJob is a snapshot of an import which runs in the background.
+type ImportJob struct {
+	ID       string            `json:"id"`
+	State    ImportJobState    `json:"state"`
+	Options  ImportJobOptions  `json:"options"`
+	Progress ImportJobProgress `json:"progress"`
+	// Report is the report of the finished import, it is missing when the import could not start.
+	Report *ImportReport `json:"report,omitempty"`
+	// Error is the reason why the job has failed.
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	// err is the error of the import, so the callers can tell its type
+	err error
+}
+
+// Err returns the error which has failed the job.
+func (j ImportJob) Err() error {
+	return j.err
+}
+
+// ImportJobOptions are the import options of the job as they are shown to the client.
+type ImportJobOptions struct {
+	Mode   ImportMode   `json:"mode"`
+	DryRun bool         `json:"dry_run,omitempty"`
+	Sync   bool         `json:"sync,omitempty"`
+	Format ImportFormat `json:"format,omitempty"`
+}
+
+// ImportJobProgress is how far the import of the job has got.
+type ImportJobProgress struct {
+	// RecordsProcessed is the number of the records which have been written or skipped.
+	RecordsProcessed int64 `json:"records_processed"`
+	RecordsDecoded   int64 `json:"records_decoded"`
+	RecordsWritten   int64 `json:"records_written"`
+	RecordsSkipped   int64 `json:"records_skipped"`
+	BytesRead        int64 `json:"bytes_read"`
+	// BytesTotal is the size of the file, it is missing when the size is not known.
+	BytesTotal int64 `json:"bytes_total,omitempty"`
+}
+
+// ImportSource provides the file of an import job.
+type ImportSource interface {
+	// Open returns the file to import, it is called when the job starts. A multipart.File, like an *os.File,
+	// is imported with SavePortsFromFile, any other file is read once as a stream with SavePortsFromStream.
+	// The file may tell its size with a Size() int64 method and its format with a Format() ImportFormat method.
+	Open(ctx context.Context) (io.ReadCloser, error)
+	// Close releases the source when the job has finished, whether Open has been called or not.
+	Close() error
+}
+
+// FileSource is a file on the local disk, the file is removed when the job has finished if Remove is true.
+type FileSource struct {
+	Path   string
+	Remove bool
+}
+
+func (s FileSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	return os.Open(s.Path)
+}
+
+func (s FileSource) Close() error {
+	if !s.Remove {
+		return nil
+	}
+	return os.Remove(s.Path)
+}
+
+// ErrImportJobNotFound is returned when there is no import job with the ID, or it has expired.
+type ErrImportJobNotFound struct {
+	ID string
+}
+
+func (e ErrImportJobNotFound) Error() string {
+	return "import job " + e.ID + " not found"
+}
+
+// ErrImportJobFinished is returned when a finished import job is cancelled.
+type ErrImportJobFinished struct {
+	ID    string
+	State ImportJobState
+}
+
+func (e ErrImportJobFinished) Error() string {
+	return fmt.Sprintf("import job %s has already %s", e.ID, e.State)
+}
+
+// ErrImportQueueFull is returned when there are too many import jobs waiting for their turn.
+type ErrImportQueueFull struct{}
+
+func (e ErrImportQueueFull) Error() string {
+	return "too many import jobs, try again later"
+}
+
+// ImportJobs runs the imports in the background with SavePortsFromFile. At most config.ImportConcurrency imports
+// run at the same time and at most config.ImportQueueSize more wait for their turn, the finished jobs are kept
+// for config.ImportJobTTL.
+type ImportJobs struct {
+	portService PortService
+	slots       chan struct{}
+	queueSize   int
+	ttl         time.Duration
+
+	mu       sync.Mutex
+	jobs     map[string]*importJob
+	waiting  int
+	finished []func(ImportJob)
+
+	// ctx is cancelled when the manager is closed, so every job is stopped
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type importJob struct {
+	ImportJob
+	progress ImportProgress
+	cancel   context.CancelFunc
+}
+
+// snapshot returns a copy of the job, it must be called with ImportJobs.mu held.
+func (j *importJob) snapshot() ImportJob {
+	job := j.ImportJob
+	if job.State == ImportJobRunning || job.State.finished() {
+		job.Progress.RecordsProcessed = j.progress.Records()
+		job.Progress.RecordsDecoded = j.progress.Decoded()
+		job.Progress.RecordsWritten = j.progress.Written()
+		job.Progress.RecordsSkipped = j.progress.Skipped()
+		job.Progress.BytesRead = j.progress.Bytes()
+	}
+	return job
+}
+
+func NewImportJobs(ps PortService, cnf config.Config) *ImportJobs {
+	concurrency := cnf.ImportConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ImportJobs{
+		portService: ps,
+		slots:       make(chan struct{}, concurrency),
+		queueSize:   cnf.ImportQueueSize,
+		ttl:         cnf.ImportJobTTL,
+		jobs:        map[string]*importJob{},
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// OnFinished registers a hook which is called with every job when it has finished.
+func (m *ImportJobs) OnFinished(hook func(ImportJob)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.finished = append(m.finished, hook)
+}
+
+// Submit starts a job which imports the file of the source, the source is closed when the job has finished.
+// The options are checked before the job is created, so an invalid option is returned as ErrInvalidQuery.
+func (m *ImportJobs) Submit(source ImportSource, opts ImportOptions) (ImportJob, error) {
+	mode, err := opts.mode()
+	if err != nil {
+		return ImportJob{}, err
+	}
+	if _, err = opts.Format.decoder(m.portService.config); err != nil {
+		return ImportJob{}, err
+	}
+
+	id, err := newImportJobID()
+	if err != nil {
+		return ImportJob{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.expire()
+	if m.waiting >= m.queueSize+cap(m.slots) {
+		return ImportJob{}, ErrImportQueueFull{}
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	job := &importJob{
+		ImportJob: ImportJob{
+			ID:        id,
+			State:     ImportJobQueued,
+			Options:   ImportJobOptions{Mode: mode, DryRun: opts.DryRun, Sync: opts.Sync, Format: opts.Format},
+			CreatedAt: time.Now().UTC(),
+		},
+		cancel: cancel,
+	}
+	m.jobs[id] = job
+	m.waiting++
+
+	m.wg.Add(1)
+	go m.run(ctx, job, source, opts)
+
+	return job.snapshot(), nil
+}
+
+// Get returns the current state of the job.
+func (m *ImportJobs) Get(id string) (ImportJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.expire()
+	job, found := m.jobs[id]
+	if !found {
+		return ImportJob{}, ErrImportJobNotFound{ID: id}
+	}
+	return job.snapshot(), nil
+}
+
+// Cancel stops the job, a queued job never starts and the import of a running one is rolled back.
+// The returned job may still be running, until its import has noticed the cancellation: the file is not read
+// anymore after the record being read, so the job finishes without reading the rest of the file.
+func (m *ImportJobs) Cancel(id string) (ImportJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, found := m.jobs[id]
+	if !found {
+		return ImportJob{}, ErrImportJobNotFound{ID: id}
+	}
+	if job.State.finished() {
+		return ImportJob{}, ErrImportJobFinished{ID: id, State: job.State}
+	}
+
+	job.cancel()
+	return job.snapshot(), nil
+}
+
+// Errors returns a channel which receives the errors of the records the job skips from now on, see
+// ImportProgress.Subscribe. The channel is closed when the job has finished, the returned function unsubscribes.
+func (m *ImportJobs) Errors(id string) (<-chan RecordError, func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, found := m.jobs[id]
+	if !found {
+		return nil, nil, ErrImportJobNotFound{ID: id}
+	}
+
+	errs, unsubscribe := job.progress.Subscribe()
+	return errs, unsubscribe, nil
+}
+
+// Close cancels all the jobs and waits until they have stopped.
+func (m *ImportJobs) Close() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+func (m *ImportJobs) run(ctx context.Context, job *importJob, source ImportSource, opts ImportOptions) {
+	defer m.wg.Done()
+
+	report, err := m.runImport(ctx, job, source, opts)
+
+	// the job is only shown as finished when it has released everything
+	source.Close() //nolint:errcheck // the job has finished, there is nobody to report to
+	cancelled := ctx.Err() != nil
+	job.cancel()
+	m.finish(job, report, err, cancelled)
+}
+
+func (m *ImportJobs) runImport(ctx context.Context, job *importJob, source ImportSource, opts ImportOptions) (*ImportReport, error) {
+	// wait for a free slot, a job cancelled in the queue never starts
+	select {
+	case m.slots <- struct{}{}:
+		defer func() {
+			<-m.slots
+		}()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	m.mu.Lock()
+	startedAt := time.Now().UTC()
+	job.State = ImportJobRunning
+	job.StartedAt = &startedAt
+	m.mu.Unlock()
+
+	file, err := source.Open(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("open source: %w", err)
+	}
+	defer file.Close()
+
+	var size int64
+	switch f := file.(type) {
+	case interface{ Stat() (os.FileInfo, error) }:
+		if info, err := f.Stat(); err == nil {
+			size = info.Size()
+		}
+	case interface{ Size() int64 }:
+		size = f.Size()
+	}
+	if size > 0 {
+		m.mu.Lock()
+		job.Progress.BytesTotal = size
+		m.mu.Unlock()
+	}
+	if f, ok := file.(interface{ Format() ImportFormat }); ok && opts.Format == "" {
+		opts.Format = f.Format()
+	}
+
+	opts.Progress = &job.progress
+	var report ImportReport
+	if f, ok := file.(multipart.File); ok {
+		report, err = m.portService.SavePortsFromFile(ctx, "", f, opts)
+	} else {
+		report, err = m.portService.SavePortsFromStream(ctx, file, "", opts)
+	}
+	return &report, err
+}
+
+// finish records the outcome of the job, an error of a job whose context has been cancelled means it has been cancelled.
+func (m *ImportJobs) finish(job *importJob, report *ImportReport, err error, cancelled bool) {
+	m.mu.Lock()
+	finishedAt := time.Now().UTC()
+	job.FinishedAt = &finishedAt
+	job.Report = report
+	job.err = err
+	m.waiting--
+
+	switch {
+	case err == nil:
+		job.State = ImportJobSucceeded
+	case cancelled:
+		job.State = ImportJobCancelled
+	default:
+		job.State = ImportJobFailed
+		job.Error = err.Error()
+	}
+
+	snapshot := job.snapshot()
+	hooks := m.finished
+	m.mu.Unlock()
+
+	// the subscribers find the job finished when their channels are closed
+	job.progress.close()
+
+	for _, hook := range hooks {
+		hook(snapshot)
+	}
+}
+
+// expire forgets the jobs which have finished more than ttl ago, it must be called with mu held.
+func (m *ImportJobs) expire() {
+	if m.ttl <= 0 {
+		return
+	}
+
+	deadline := time.Now().Add(-m.ttl)
+	for id, job := range m.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(deadline) {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+func newImportJobID() (string, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", fmt.Errorf("generate import job id: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
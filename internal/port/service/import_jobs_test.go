@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fir1/port/config"
+	"github.com/fir1/port/internal/port/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingSource blocks the job in Open until it is cancelled.
+type blockingSource struct {
+	closed *atomic.Bool
+}
+
+func (s blockingSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s blockingSource) Close() error {
+	s.closed.Store(true)
+	return nil
+}
+
+// gatedReader reads the data until the gate, it then waits for the gate to open before it reads the rest.
+type gatedReader struct {
+	data   []byte
+	gateAt int
+	gate   chan struct{}
+	read   atomic.Int64
+}
+
+func (r *gatedReader) Read(p []byte) (int, error) {
+	offset := int(r.read.Load())
+	if offset >= len(r.data) {
+		return 0, io.EOF
+	}
+	if offset >= r.gateAt {
+		<-r.gate
+	} else if len(p) > r.gateAt-offset {
+		p = p[:r.gateAt-offset]
+	}
+
+	n := copy(p, r.data[offset:])
+	r.read.Add(int64(n))
+	return n, nil
+}
+
+// readerSource is the source of a job which streams the reader.
+type readerSource struct {
+	reader io.Reader
+}
+
+func (s readerSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	return io.NopCloser(s.reader), nil
+}
+
+func (s readerSource) Close() error {
+	return nil
+}
+
+func waitForImportJob(t *testing.T, jobs *ImportJobs, id string) ImportJob {
+	t.Helper()
+
+	var job ImportJob
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = jobs.Get(id)
+		require.NoError(t, err)
+		return job.State.finished()
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestImportJobs(t *testing.T) {
+	path := writeTestFile(t, syncTestFile)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+	jobs := NewImportJobs(portService, config.Config{ImportConcurrency: 1, ImportQueueSize: 1, ImportJobTTL: time.Hour})
+	defer jobs.Close()
+
+	var finished atomic.Int32
+	jobs.OnFinished(func(job ImportJob) {
+		finished.Add(1)
+	})
+
+	job, err := jobs.Submit(FileSource{Path: path}, ImportOptions{Mode: ImportModeLenient})
+	require.NoError(t, err)
+	assert.NotEmpty(t, job.ID)
+	assert.Equal(t, ImportModeLenient, job.Options.Mode)
+
+	job = waitForImportJob(t, jobs, job.ID)
+	assert.Equal(t, ImportJobSucceeded, job.State)
+	require.NotNil(t, job.Report)
+	assert.Equal(t, 3, job.Report.Created)
+	assert.Equal(t, int64(3), job.Progress.RecordsProcessed)
+	assert.Equal(t, int64(3), job.Progress.RecordsDecoded)
+	assert.Equal(t, int64(3), job.Progress.RecordsWritten)
+	assert.Equal(t, info.Size(), job.Progress.BytesRead)
+	assert.Equal(t, info.Size(), job.Progress.BytesTotal)
+	assert.NotNil(t, job.StartedAt)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, int32(1), finished.Load())
+
+	_, err = jobs.Cancel(job.ID)
+	assert.ErrorAs(t, err, &ErrImportJobFinished{})
+
+	// the errors of a finished job are closed at once
+	errs, unsubscribe, err := jobs.Errors(job.ID)
+	require.NoError(t, err)
+	_, open := <-errs
+	assert.False(t, open)
+	unsubscribe()
+
+	_, err = jobs.Get("missing")
+	assert.ErrorAs(t, err, &ErrImportJobNotFound{})
+
+	_, err = jobs.Submit(FileSource{Path: path}, ImportOptions{Mode: "partial"})
+	assert.ErrorAs(t, err, &ErrInvalidQuery{})
+
+	_, err = os.Stat(path)
+	assert.NoError(t, err, "the file must only be removed when it is asked for")
+}
+
+func TestImportJobs_Cancel(t *testing.T) {
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+	jobs := NewImportJobs(portService, config.Config{ImportConcurrency: 1, ImportQueueSize: 1})
+	defer jobs.Close()
+
+	var runningClosed, queuedClosed atomic.Bool
+	running, err := jobs.Submit(blockingSource{closed: &runningClosed}, ImportOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		job, err := jobs.Get(running.ID)
+		return err == nil && job.State == ImportJobRunning
+	}, 5*time.Second, 10*time.Millisecond)
+
+	queued, err := jobs.Submit(blockingSource{closed: &queuedClosed}, ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, ImportJobQueued, queued.State)
+
+	// one job runs and one waits, there is no room for another one
+	_, err = jobs.Submit(blockingSource{closed: &atomic.Bool{}}, ImportOptions{})
+	assert.ErrorAs(t, err, &ErrImportQueueFull{})
+
+	_, err = jobs.Cancel(queued.ID)
+	require.NoError(t, err)
+	job := waitForImportJob(t, jobs, queued.ID)
+	assert.Equal(t, ImportJobCancelled, job.State)
+	assert.Nil(t, job.StartedAt, "a job cancelled in the queue must never start")
+	assert.True(t, queuedClosed.Load())
+
+	job, err = jobs.Get(running.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ImportJobRunning, job.State)
+
+	_, err = jobs.Cancel(running.ID)
+	require.NoError(t, err)
+	job = waitForImportJob(t, jobs, running.ID)
+	assert.Equal(t, ImportJobCancelled, job.State)
+	assert.True(t, runningClosed.Load())
+}
+
+func TestImportJobs_CancelStopsReading(t *testing.T) {
+	data, err := os.ReadFile(largeTestFile(t, 10000))
+	require.NoError(t, err)
+	reader := &gatedReader{data: data, gateAt: 4096, gate: make(chan struct{})}
+
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+	jobs := NewImportJobs(portService, config.Config{ImportConcurrency: 1, ImportQueueSize: 1})
+	defer jobs.Close()
+
+	job, err := jobs.Submit(readerSource{reader: reader}, ImportOptions{Mode: ImportModeLenient})
+	require.NoError(t, err)
+
+	// the job has imported the first ports and waits for the rest of the file
+	require.Eventually(t, func() bool {
+		job, err := jobs.Get(job.ID)
+		return err == nil && job.Progress.RecordsDecoded > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err = jobs.Cancel(job.ID)
+	require.NoError(t, err)
+	close(reader.gate)
+
+	job = waitForImportJob(t, jobs, job.ID)
+	assert.Equal(t, ImportJobCancelled, job.State)
+	assert.Less(t, reader.read.Load(), int64(len(data)/10), "the rest of the file must not be read")
+}
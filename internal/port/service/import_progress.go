@@ -0,0 +1,166 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fir1/port/internal/port/validation"
+)
+
+// progressErrorBuffer is the number of the record errors a subscriber may fall behind before they are dropped for it.
+const progressErrorBuffer = 64
+
+// ImportProgress counts the work done by a running import and sends the record errors to its subscribers
+// as they happen, it is safe for concurrent use.
+type ImportProgress struct {
+	bytes   atomic.Int64
+	decoded atomic.Int64
+	written atomic.Int64
+	skipped atomic.Int64
+
+	mu          sync.Mutex
+	subscribers map[chan RecordError]struct{}
+	closed      bool
+}
+
+// Records returns the number of the records of the file which have been imported or skipped.
+func (p *ImportProgress) Records() int64 {
+	return p.written.Load() + p.skipped.Load()
+}
+
+// Bytes returns the number of the bytes read from the file. The file is read ahead of the imported records,
+// so it runs slightly ahead of Records.
+func (p *ImportProgress) Bytes() int64 {
+	return p.bytes.Load()
+}
+
+// Decoded returns the number of the records read from the file, whether they could be decoded or not.
+func (p *ImportProgress) Decoded() int64 {
+	return p.decoded.Load()
+}
+
+// Written returns the number of the records which have been imported, or previewed by a dry run.
+func (p *ImportProgress) Written() int64 {
+	return p.written.Load()
+}
+
+// Skipped returns the number of the records which could not be imported.
+func (p *ImportProgress) Skipped() int64 {
+	return p.skipped.Load()
+}
+
+// Subscribe returns a channel which receives the errors of the records skipped from now on. The channel is closed
+// when the import has finished or when the returned function is called. A subscriber which falls behind misses
+// the errors, the count of the skipped records is always right.
+func (p *ImportProgress) Subscribe() (<-chan RecordError, func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	errs := make(chan RecordError, progressErrorBuffer)
+	if p.closed {
+		close(errs)
+		return errs, func() {}
+	}
+
+	if p.subscribers == nil {
+		p.subscribers = map[chan RecordError]struct{}{}
+	}
+	p.subscribers[errs] = struct{}{}
+	return errs, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		if _, found := p.subscribers[errs]; found {
+			delete(p.subscribers, errs)
+			close(errs)
+		}
+	}
+}
+
+// recordDecoded counts a record read from the file, it is a no-op for a nil progress like the other counters.
+func (p *ImportProgress) recordDecoded(Entry) {
+	if p != nil {
+		p.decoded.Add(1)
+	}
+}
+
+func (p *ImportProgress) recordWritten() {
+	if p != nil {
+		p.written.Add(1)
+	}
+}
+
+// recordSkipped counts a skipped record and sends its error to the subscribers.
+func (p *ImportProgress) recordSkipped(entry Entry, err error) {
+	if p == nil {
+		return
+	}
+	p.skipped.Add(1)
+
+	recordErr := newRecordError(entry, err)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for errs := range p.subscribers {
+		select {
+		case errs <- recordErr:
+		default:
+		}
+	}
+}
+
+// close closes the channels of the subscribers, the later subscribers get a closed channel.
+func (p *ImportProgress) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for errs := range p.subscribers {
+		close(errs)
+	}
+	p.subscribers = nil
+}
+
+// reader counts the bytes read through it, the reader is returned as it is for a nil progress.
+func (p *ImportProgress) reader(r io.Reader) io.Reader {
+	if p == nil {
+		return r
+	}
+	return progressReader{reader: r, progress: p}
+}
+
+// progressReaderAt counts the bytes read through it by the progress, it counts nothing while the progress is nil.
+type progressReaderAt struct {
+	reader   io.ReaderAt
+	progress *ImportProgress
+}
+
+func (r *progressReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	n, err := r.reader.ReadAt(b, off)
+	if r.progress != nil {
+		r.progress.bytes.Add(int64(n))
+	}
+	return n, err
+}
+
+type progressReader struct {
+	reader   io.Reader
+	progress *ImportProgress
+}
+
+func (r progressReader) Read(b []byte) (int, error) {
+	n, err := r.reader.Read(b)
+	r.progress.bytes.Add(int64(n))
+	return n, err
+}
+
+func newRecordError(entry Entry, err error) RecordError {
+	recordErr := RecordError{Record: entry.Record, File: entry.File, Unlocode: entry.PortCode, Message: err.Error()}
+	var validationErr validation.ValidationError
+	if errors.As(err, &validationErr) {
+		recordErr.Violations = validationErr.Violations
+	}
+	return recordErr
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/internal/port/validation"
+)
+
+// ImportMode tells how an import handles the records which can not be imported.
+type ImportMode string
+
+const (
+	// ImportModeStrict stops the import at the first invalid record, nothing is saved then.
+	ImportModeStrict ImportMode = "strict"
+	// ImportModeLenient skips the invalid records and reports them, the rest of the file is imported.
+	ImportModeLenient ImportMode = "lenient"
+)
+
+// MaxReportErrors is the maximum number of the record errors listed by an ImportReport.
+const MaxReportErrors = 1000
+
+// ImportOptions are the options of SavePortsFromFile.
+type ImportOptions struct {
+	// Mode is ImportModeStrict when it is empty.
+	Mode ImportMode
+	// DryRun compares the ports of the file with the stored ones and reports the diff without writing anything.
+	DryRun bool
+	// Progress is updated while the file is imported, it is optional.
+	Progress *ImportProgress
+	// Sync deletes the stored ports which are missing from the file. It can not be combined with ImportModeLenient,
+	// as the skipped ports would be deleted.
+	Sync bool
+	// Format is the format of the file. When it is empty, it is detected by the extension of the file path,
+	// an uploaded file is ImportFormatJSON then.
+	Format ImportFormat
+}
+
+func (o ImportOptions) mode() (ImportMode, error) {
+	if o.Sync && o.Mode == ImportModeLenient {
+		return "", ErrInvalidQuery{Reasons: []string{"sync can not be combined with the lenient mode"}}
+	}
+
+	switch o.Mode {
+	case "":
+		return ImportModeStrict, nil
+	case ImportModeStrict, ImportModeLenient:
+		return o.Mode, nil
+	default:
+		return "", ErrInvalidQuery{Reasons: []string{"mode must be one of: strict, lenient"}}
+	}
+}
+
+// ImportReport is the outcome of an import.
+type ImportReport struct {
+	Mode   ImportMode `json:"mode"`
+	DryRun bool       `json:"dry_run,omitempty"`
+	Sync   bool       `json:"sync,omitempty"`
+	// Created, Updated and Unchanged count the ports saved by the import, Unchanged ports are not written.
+	// In a dry run they count the ports which would be saved.
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	// Deleted counts the ports deleted by a sync.
+	Deleted int `json:"deleted"`
+	// RolledBack is true when the import has been stopped by an error, the counts tell what had been done
+	// before the writes were rolled back.
+	RolledBack bool `json:"rolled_back,omitempty"`
+	// Skipped counts the records which were not imported because of an error.
+	Skipped int           `json:"skipped"`
+	Errors  []RecordError `json:"errors"`
+	// ErrorsTruncated is true when there were more than MaxReportErrors errors.
+	ErrorsTruncated bool `json:"errors_truncated,omitempty"`
+	// Diff lists the added and the changed ports of a dry run in the order of the file, followed by the ports
+	// removed by a sync.
+	Diff       []PortDiff `json:"diff,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt time.Time  `json:"finished_at"`
+	DurationMs int64      `json:"duration_ms"`
+}
+
+// RecordError is the reason why a record of the file was not imported.
+type RecordError struct {
+	// Record is the position of the port in the file, starting from 1.
+	Record int `json:"record"`
+	// File is the archive member of the record, it is empty unless an archive has been imported.
+	File       string                 `json:"file,omitempty"`
+	Unlocode   string                 `json:"unlocode"`
+	Message    string                 `json:"message"`
+	Violations []validation.Violation `json:"violations,omitempty"`
+}
+
+// importOutcome is what happened to a port which has been imported.
+type importOutcome int
+
+const (
+	outcomeCreated importOutcome = iota
+	outcomeUpdated
+	outcomeUnchanged
+	outcomeDeleted
+)
+
+// reportBuilder collects the report of an import from the concurrent workers.
+type reportBuilder struct {
+	mu     sync.Mutex
+	report ImportReport
+}
+
+func newReportBuilder(mode ImportMode, dryRun bool) *reportBuilder {
+	report := ImportReport{
+		Mode:      mode,
+		DryRun:    dryRun,
+		Errors:    []RecordError{},
+		StartedAt: time.Now().UTC(),
+	}
+	if dryRun {
+		report.Diff = []PortDiff{}
+	}
+	return &reportBuilder{report: report}
+}
+
+func (b *reportBuilder) add(outcome importOutcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch outcome {
+	case outcomeCreated:
+		b.report.Created++
+	case outcomeUpdated:
+		b.report.Updated++
+	case outcomeUnchanged:
+		b.report.Unchanged++
+	case outcomeDeleted:
+		b.report.Deleted++
+	}
+}
+
+// diff adds the diff of a port to the report, it is a no-op unless the report is of a dry run.
+func (b *reportBuilder) diff(diff PortDiff) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.report.DryRun {
+		return
+	}
+
+	b.report.Diff = append(b.report.Diff, diff)
+}
+
+func (b *reportBuilder) skip(entry Entry, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.report.Skipped++
+	if len(b.report.Errors) >= MaxReportErrors {
+		b.report.ErrorsTruncated = true
+		return
+	}
+
+	b.report.Errors = append(b.report.Errors, newRecordError(entry, err))
+}
+
+func (b *reportBuilder) rolledBack() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.report.RolledBack = true
+}
+
+func (b *reportBuilder) finish() ImportReport {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// the workers finish in any order, the removed ports have no record
+	sort.Slice(b.report.Diff, func(i, j int) bool {
+		a, c := b.report.Diff[i], b.report.Diff[j]
+		if (a.Record == 0) != (c.Record == 0) {
+			return c.Record == 0
+		}
+		if a.File != c.File {
+			return a.File < c.File
+		}
+		if a.Record != c.Record {
+			return a.Record < c.Record
+		}
+		return a.Unlocode < c.Unlocode
+	})
+
+	b.report.FinishedAt = time.Now().UTC()
+	b.report.DurationMs = b.report.FinishedAt.Sub(b.report.StartedAt).Milliseconds()
+	return b.report
+}
+
+// samePort tells whether importing the port would not change the stored one, the nil and the empty slices
+// are equal as the repositories do not tell them apart.
+func samePort(stored, imported model.Port) bool {
+	return reflect.DeepEqual(normalizePort(stored), normalizePort(imported))
+}
+
+func normalizePort(port model.Port) model.Port {
+	if len(port.Alias) == 0 {
+		port.Alias = nil
+	}
+	if len(port.Regions) == 0 {
+		port.Regions = nil
+	}
+	if len(port.Coordinates) == 0 {
+		port.Coordinates = nil
+	}
+	if len(port.Unlocs) == 0 {
+		port.Unlocs = nil
+	}
+	return port
+}
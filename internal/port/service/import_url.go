@@ -0,0 +1,343 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"  //nolint:gosec // the checksums of the data exports, not a security feature
+	"crypto/sha1" //nolint:gosec // the checksums of the data exports, not a security feature
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avast/retry-go"
+	"github.com/fir1/port/config"
+)
+
+// checksumHashes are the algorithms of the checksums a download can be verified with.
+var checksumHashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// Checksum is the expected checksum of a downloaded file.
+type Checksum struct {
+	Algorithm string
+	Sum       []byte
+}
+
+// ParseChecksum parses a checksum written as "<algorithm>:<hex digest>", e.g. "sha256:9f86d081...". The algorithms
+// are md5, sha1, sha256 and sha512, a digest without the algorithm is a sha256 one. An empty checksum is no checksum.
+func ParseChecksum(checksum string) (*Checksum, error) {
+	if checksum == "" {
+		return nil, nil
+	}
+
+	algorithm, digest, found := strings.Cut(checksum, ":")
+	if !found {
+		algorithm, digest = "sha256", checksum
+	}
+	algorithm = strings.ToLower(algorithm)
+
+	newHash, supported := checksumHashes[algorithm]
+	if !supported {
+		return nil, ErrInvalidQuery{Reasons: []string{"checksum algorithm must be one of: md5, sha1, sha256, sha512"}}
+	}
+	sum, err := hex.DecodeString(digest)
+	if err != nil || len(sum) != newHash().Size() {
+		return nil, ErrInvalidQuery{Reasons: []string{fmt.Sprintf("checksum must be a hex encoded %s digest", algorithm)}}
+	}
+	return &Checksum{Algorithm: algorithm, Sum: sum}, nil
+}
+
+// ErrChecksumMismatch is returned when a downloaded file does not match its checksum.
+type ErrChecksumMismatch struct {
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+func (e ErrChecksumMismatch) Error() string {
+	return fmt.Sprintf("%s checksum mismatch: expected %s, got %s", e.Algorithm, e.Expected, e.Actual)
+}
+
+// URLSource downloads the file of an import job from an HTTP or an HTTPS URL. The file is streamed to the import
+// as it is downloaded: a dropped connection is resumed from where it has stopped with a Range request, and
+// the requests which fail for a reason which may go away, like a network error or a 503, are retried. The checksum
+// is verified when the whole file has been read, so a file which does not match it is rolled back.
+type URLSource struct {
+	URL      string
+	Checksum *Checksum
+	// Client sends the requests, http.DefaultClient when it is nil.
+	Client *http.Client
+	// Attempts is the number of the attempts of every request, the delay between them doubles from RetryDelay.
+	Attempts   uint
+	RetryDelay time.Duration
+	// Timeout is how long the server may keep a request waiting for the response or for the next bytes
+	// of the body, a request which times out is retried or resumed. It is DefaultDownloadTimeout when it is zero.
+	Timeout time.Duration
+}
+
+// DefaultDownloadTimeout is the timeout of the requests of a URLSource without one.
+const DefaultDownloadTimeout = 30 * time.Second
+
+// NewURLSource returns the source of the URL with the retries of the config, the URL and the checksum
+// are checked, an invalid one is ErrInvalidQuery.
+func NewURLSource(cnf config.Config, sourceURL, checksum string) (URLSource, error) {
+	u, err := url.Parse(sourceURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return URLSource{}, ErrInvalidQuery{Reasons: []string{"source_url must be an absolute http or https URL"}}
+	}
+
+	sum, err := ParseChecksum(checksum)
+	if err != nil {
+		return URLSource{}, err
+	}
+
+	return URLSource{
+		URL:        u.String(),
+		Checksum:   sum,
+		Attempts:   cnf.ImportDownloadAttempts,
+		RetryDelay: cnf.ImportDownloadRetryDelay,
+		Timeout:    cnf.ImportDownloadTimeout,
+	}, nil
+}
+
+// Open requests the file, the returned download reads its body.
+func (s URLSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	d := &download{source: s, ctx: ctx, size: -1}
+	if s.Checksum != nil {
+		d.hash = checksumHashes[s.Checksum.Algorithm]()
+	}
+
+	err := d.request()
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Close has nothing to release, the download is closed by the job.
+func (s URLSource) Close() error {
+	return nil
+}
+
+func (s URLSource) timeout() time.Duration {
+	if s.Timeout <= 0 {
+		return DefaultDownloadTimeout
+	}
+	return s.Timeout
+}
+
+// download reads the body of a URLSource, it resumes the download when the connection drops.
+type download struct {
+	source URLSource
+	ctx    context.Context
+
+	body io.ReadCloser
+	// stall cancels the request of the body when the server sends nothing for the timeout
+	stall  *time.Timer
+	cancel context.CancelFunc
+	offset int64
+	// size is the size of the file, it is -1 when the server has not told it
+	size int64
+	// validator makes sure that a resumed download reads the same file, it is the ETag or the Last-Modified
+	validator   string
+	contentType string
+	hash        hash.Hash
+}
+
+// Size returns the size of the file, it is -1 when it is not known.
+func (d *download) Size() int64 {
+	return d.size
+}
+
+// Format returns the format told by the content type of the response or by the extension of the URL.
+func (d *download) Format() ImportFormat {
+	u, err := url.Parse(d.source.URL)
+	if err != nil {
+		return DetectImportFormat(d.contentType, "")
+	}
+	return DetectImportFormat(d.contentType, path.Base(u.Path))
+}
+
+func (d *download) Read(p []byte) (int, error) {
+	for {
+		d.stall.Reset(d.source.timeout())
+		n, err := d.body.Read(p)
+		if !d.stall.Stop() && err != nil && d.ctx.Err() == nil {
+			err = fmt.Errorf("no data for %s", d.source.timeout())
+		}
+		d.offset += int64(n)
+		if d.hash != nil {
+			d.hash.Write(p[:n])
+		}
+
+		if errors.Is(err, io.EOF) && d.size >= 0 && d.offset < d.size {
+			err = io.ErrUnexpectedEOF
+		}
+		switch {
+		case err == nil:
+			return n, nil
+		case errors.Is(err, io.EOF):
+			return n, d.verify()
+		case d.ctx.Err() != nil:
+			return n, d.ctx.Err()
+		}
+
+		// the connection has dropped, the rest of the file is requested
+		d.closeBody()
+		resumeErr := d.request()
+		if resumeErr != nil {
+			return n, fmt.Errorf("resume download at byte %d after %v: %w", d.offset, err, resumeErr)
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (d *download) Close() error {
+	if d.body == nil {
+		return nil
+	}
+	return d.closeBody()
+}
+
+func (d *download) closeBody() error {
+	err := d.body.Close()
+	d.stall.Stop()
+	d.cancel()
+	return err
+}
+
+func (d *download) verify() error {
+	if d.hash == nil {
+		return io.EOF
+	}
+
+	sum := d.hash.Sum(nil)
+	if !bytes.Equal(sum, d.source.Checksum.Sum) {
+		return ErrChecksumMismatch{
+			Algorithm: d.source.Checksum.Algorithm,
+			Expected:  hex.EncodeToString(d.source.Checksum.Sum),
+			Actual:    hex.EncodeToString(sum),
+		}
+	}
+	return io.EOF
+}
+
+// request requests the file from the offset, the request is retried while it fails for a reason which may go away.
+func (d *download) request() error {
+	attempts := d.source.Attempts
+	if attempts == 0 {
+		attempts = 1
+	}
+
+	return retry.Do(d.requestOnce,
+		retry.Context(d.ctx),
+		retry.Attempts(attempts),
+		retry.Delay(d.source.RetryDelay),
+		retry.DelayType(retry.BackOffDelay),
+		retry.LastErrorOnly(true),
+	)
+}
+
+func (d *download) requestOnce() error {
+	ctx, cancel := context.WithCancel(d.ctx)
+	// a server which stops answering cancels the request, so it is retried instead of hanging the import
+	stall := time.AfterFunc(d.source.timeout(), cancel)
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			stall.Stop()
+			cancel()
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.source.URL, nil)
+	if err != nil {
+		return retry.Unrecoverable(fmt.Errorf("create request: %w", err))
+	}
+	// the file is read as it is stored, so the offsets of the Range requests are the ones of the file
+	req.Header.Set("Accept-Encoding", "identity")
+	if d.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", d.offset))
+		if d.validator != "" {
+			req.Header.Set("If-Range", d.validator)
+		}
+	}
+
+	client := d.source.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	stall.Stop()
+	if err != nil {
+		if d.ctx.Err() != nil {
+			return retry.Unrecoverable(d.ctx.Err())
+		}
+		return fmt.Errorf("download %s: %w", d.source.URL, err)
+	}
+
+	switch {
+	case d.offset == 0 && resp.StatusCode == http.StatusOK:
+		d.size = resp.ContentLength
+		d.contentType = resp.Header.Get("Content-Type")
+		// If-Range only takes a strong ETag
+		d.validator = resp.Header.Get("ETag")
+		if d.validator == "" || strings.HasPrefix(d.validator, "W/") {
+			d.validator = resp.Header.Get("Last-Modified")
+		}
+	case d.offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		start, err := contentRangeStart(resp.Header.Get("Content-Range"))
+		if err != nil || start != d.offset {
+			resp.Body.Close()
+			return retry.Unrecoverable(fmt.Errorf("download %s: the server has resumed at a wrong byte: %q",
+				d.source.URL, resp.Header.Get("Content-Range")))
+		}
+	case d.offset > 0 && resp.StatusCode == http.StatusOK:
+		// the server ignores the Range, or the file has changed and If-Range has asked for the new one
+		resp.Body.Close()
+		return retry.Unrecoverable(fmt.Errorf("download %s: the download can not be resumed, the server has sent the whole file",
+			d.source.URL))
+	default:
+		resp.Body.Close()
+		err := fmt.Errorf("download %s: unexpected status %s", d.source.URL, resp.Status)
+		if !retryableStatus(resp.StatusCode) {
+			return retry.Unrecoverable(err)
+		}
+		return err
+	}
+
+	d.body, d.stall, d.cancel = resp.Body, stall, cancel
+	succeeded = true
+	return nil
+}
+
+// retryableStatus tells whether a request may succeed when it is sent again.
+func retryableStatus(status int) bool {
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// contentRangeStart returns the first byte of a Content-Range header, e.g. 100 for "bytes 100-199/200".
+func contentRangeStart(contentRange string) (int64, error) {
+	rangeSpec, found := strings.CutPrefix(contentRange, "bytes ")
+	if !found {
+		return 0, fmt.Errorf("invalid content range %q", contentRange)
+	}
+	start, _, _ := strings.Cut(rangeSpec, "-")
+	return strconv.ParseInt(start, 10, 64)
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fir1/port/config"
+	"github.com/fir1/port/internal/port/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const urlTestPorts = `{"code": "AEAJM", "port": {"name": "Ajman", "city": "Ajman", "country": "United Arab Emirates", "unlocs": ["AEAJM"]}}
+{"code": "AEAUH", "port": {"name": "Abu Dhabi", "city": "Abu Dhabi", "country": "United Arab Emirates", "unlocs": ["AEAUH"]}}
+{"code": "AEDXB", "port": {"name": "Dubai", "city": "Dubai", "country": "United Arab Emirates", "unlocs": ["AEDXB"]}}
+`
+
+func sha256Checksum(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// serveFile serves the data with the Range requests supported, like a file server does.
+func serveFile(w http.ResponseWriter, r *http.Request, data string) {
+	w.Header().Set("ETag", `"ports-v1"`)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader([]byte(data)))
+}
+
+func newTestURLSource(t *testing.T, url, checksum string) URLSource {
+	t.Helper()
+
+	source, err := NewURLSource(config.Config{ImportDownloadAttempts: 3, ImportDownloadRetryDelay: time.Millisecond},
+		url, checksum)
+	require.NoError(t, err)
+	return source
+}
+
+func TestURLSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/exports/ports.ndjson", r.URL.Path)
+		serveFile(w, r, urlTestPorts)
+	}))
+	defer server.Close()
+
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+	jobs := NewImportJobs(portService, config.Config{ImportConcurrency: 1, ImportQueueSize: 1, ImportJobTTL: time.Hour})
+	defer jobs.Close()
+
+	// the format is told by the extension of the URL
+	source := newTestURLSource(t, server.URL+"/exports/ports.ndjson", sha256Checksum(urlTestPorts))
+	job, err := jobs.Submit(source, ImportOptions{})
+	require.NoError(t, err)
+
+	job = waitForImportJob(t, jobs, job.ID)
+	require.Equal(t, ImportJobSucceeded, job.State, job.Error)
+	assert.Equal(t, 3, job.Report.Created)
+	assert.Equal(t, int64(len(urlTestPorts)), job.Progress.BytesRead)
+	assert.Equal(t, int64(len(urlTestPorts)), job.Progress.BytesTotal)
+}
+
+func TestURLSource_Resume(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		ranges []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		first := len(ranges) == 1
+		mu.Unlock()
+
+		if !first {
+			assert.Equal(t, `"ports-v1"`, r.Header.Get("If-Range"))
+			serveFile(w, r, urlTestPorts)
+			return
+		}
+
+		// the connection drops in the middle of the second port
+		w.Header().Set("ETag", `"ports-v1"`)
+		w.Header().Set("Content-Length", "200")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(urlTestPorts[:150]))
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}))
+	defer server.Close()
+
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+	source := newTestURLSource(t, server.URL+"/ports.ndjson", sha256Checksum(urlTestPorts))
+
+	file, err := source.Open(context.Background())
+	require.NoError(t, err)
+	defer file.Close()
+
+	report, err := portService.SavePortsFromStream(context.Background(), file, "", ImportOptions{Format: ImportFormatNDJSON})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Created)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"", "bytes=150-"}, ranges)
+}
+
+func TestURLSource_Stalled(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) > 1 {
+			serveFile(w, r, urlTestPorts)
+			return
+		}
+
+		// the server stops sending in the middle of the second port without closing the connection
+		w.Header().Set("ETag", `"ports-v1"`)
+		w.Header().Set("Content-Length", strconv.Itoa(len(urlTestPorts)))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(urlTestPorts[:150]))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+	source := newTestURLSource(t, server.URL+"/ports.ndjson", sha256Checksum(urlTestPorts))
+	source.Timeout = 100 * time.Millisecond
+
+	file, err := source.Open(context.Background())
+	require.NoError(t, err)
+	defer file.Close()
+
+	report, err := portService.SavePortsFromStream(context.Background(), file, "", ImportOptions{Format: ImportFormatNDJSON})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Created)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestURLSource_ChecksumMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveFile(w, r, urlTestPorts)
+	}))
+	defer server.Close()
+
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+	source := newTestURLSource(t, server.URL+"/ports.ndjson", sha256Checksum("other ports"))
+
+	file, err := source.Open(context.Background())
+	require.NoError(t, err)
+	defer file.Close()
+
+	report, err := portService.SavePortsFromStream(context.Background(), file, "", ImportOptions{Format: ImportFormatNDJSON})
+	assert.ErrorAs(t, err, &ErrChecksumMismatch{})
+	assert.True(t, report.RolledBack)
+
+	ports, err := portService.ListPorts(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, ports, "a file which does not match its checksum must be rolled back")
+}
+
+func TestURLSource_Retries(t *testing.T) {
+	testCases := map[string]struct {
+		failures int
+		status   int
+		requests int32
+		wantErr  bool
+	}{
+		"retried until it succeeds": {failures: 2, status: http.StatusServiceUnavailable, requests: 3},
+		"retried until the attempts run out": {
+			failures: 5, status: http.StatusServiceUnavailable, requests: 3, wantErr: true,
+		},
+		"not retried when it can not succeed": {failures: 5, status: http.StatusNotFound, requests: 1, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if int(requests.Add(1)) <= tc.failures {
+					w.WriteHeader(tc.status)
+					return
+				}
+				serveFile(w, r, urlTestPorts)
+			}))
+			defer server.Close()
+
+			source := newTestURLSource(t, server.URL+"/ports.ndjson", "")
+			file, err := source.Open(context.Background())
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				file.Close()
+			}
+			assert.Equal(t, tc.requests, requests.Load())
+		})
+	}
+}
+
+func TestNewURLSource(t *testing.T) {
+	source, err := NewURLSource(config.Config{ImportDownloadAttempts: 5, ImportDownloadRetryDelay: time.Second},
+		"https://example.com/ports.json", "SHA1:da39a3ee5e6b4b0d3255bfef95601890afd80709")
+	require.NoError(t, err)
+	assert.Equal(t, uint(5), source.Attempts)
+	assert.Equal(t, time.Second, source.RetryDelay)
+	require.NotNil(t, source.Checksum)
+	assert.Equal(t, "sha1", source.Checksum.Algorithm)
+
+	source, err = NewURLSource(config.Config{}, "http://example.com/ports.json", "")
+	require.NoError(t, err)
+	assert.Nil(t, source.Checksum)
+
+	for _, invalid := range []struct{ url, checksum string }{
+		{"", ""},
+		{"ports.json", ""},
+		{"ftp://example.com/ports.json", ""},
+		{"file:///data/ports.json", ""},
+		{"https://example.com/ports.json", "crc32:d87f7e0c"},
+		{"https://example.com/ports.json", "sha256:abc"},
+		{"https://example.com/ports.json", "not hex"},
+	} {
+		_, err := NewURLSource(config.Config{}, invalid.url, invalid.checksum)
+		assert.ErrorAs(t, err, &ErrInvalidQuery{}, "%+v", invalid)
+	}
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fir1/port/config"
+)
+
+// The folders of the watched directory which the imported files are moved to.
+const (
+	WatchProcessedDir = "processed"
+	WatchFailedDir    = "failed"
+)
+
+// watchReportSuffix is appended to the name of a moved file to name its report.
+const watchReportSuffix = ".report.json"
+
+// WatchedImport is the result of the import of a file found by an ImportWatcher, it is written in JSON next to
+// the moved file.
+type WatchedImport struct {
+	// File is the name of the file in the watched directory.
+	File string `json:"file"`
+	// Path is where the file has been moved to, it is empty when the file could not be moved.
+	Path   string       `json:"path,omitempty"`
+	Report ImportReport `json:"report"`
+	Error  string       `json:"error,omitempty"`
+	// Err is the error of the import or of the move of the file.
+	Err error `json:"-"`
+}
+
+// watchedFile is the state of a file of the watched directory.
+type watchedFile struct {
+	size    int64
+	modTime time.Time
+	// changedAt is when the watcher has seen the file change for the last time
+	changedAt time.Time
+	// pending is true while the file waits to be imported
+	pending bool
+}
+
+// ImportWatcher imports the port files dropped into a directory, e.g. by an ETL which refreshes them on a shared
+// volume. The directory is scanned every interval, so it works on the volumes which do not report their changes.
+// A new or changed file is imported with SavePortsFromFile once it has not changed for the debounce, so a file
+// which is still being written is not imported. The imported file is moved to the processed folder, or to
+// the failed one when the import has failed, with the report of the import next to it.
+//
+// The directory is a folder of its own, config.ImportWatchDir, rather than the data directory, so the files
+// the service reads from the data directory, like the ports.json of POST /ports/from-file, are never moved.
+// The files in the directory when the watcher starts are imported like the new ones, the files of the other
+// extensions are ignored.
+type ImportWatcher struct {
+	portService PortService
+	dir         string
+	dataDir     string
+	interval    time.Duration
+	debounce    time.Duration
+	mode        ImportMode
+
+	mu       sync.Mutex
+	imported []func(WatchedImport)
+
+	files  map[string]watchedFile
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewImportWatcher returns the watcher of config.ImportWatchDir, a relative directory is in config.DataDir.
+// It does nothing until it is started.
+func NewImportWatcher(ps PortService, cnf config.Config) *ImportWatcher {
+	dir := cnf.ImportWatchDir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(cnf.DataDir, dir)
+	}
+
+	return &ImportWatcher{
+		portService: ps,
+		dir:         dir,
+		dataDir:     cnf.DataDir,
+		interval:    cnf.ImportWatchInterval,
+		debounce:    cnf.ImportWatchDebounce,
+		mode:        ImportMode(cnf.ImportWatchMode),
+	}
+}
+
+// OnImported registers a hook which is called with every file imported by the watcher, whether it has succeeded
+// or not.
+func (w *ImportWatcher) OnImported(hook func(WatchedImport)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.imported = append(w.imported, hook)
+}
+
+// Start creates the directory when it is missing and starts watching it. The mode is checked before,
+// an invalid mode is ErrInvalidQuery.
+func (w *ImportWatcher) Start() error {
+	_, err := ImportOptions{Mode: w.mode}.mode()
+	if err != nil {
+		return err
+	}
+	if w.interval <= 0 {
+		return fmt.Errorf("watch interval must be positive, got %s", w.interval)
+	}
+	if filepath.Clean(w.dir) == filepath.Clean(w.dataDir) {
+		return fmt.Errorf("the watched directory must be a folder of its own, not the data directory %s", w.dataDir)
+	}
+
+	err = os.MkdirAll(w.dir, 0o755)
+	if err != nil {
+		return fmt.Errorf("create watched directory: %w", err)
+	}
+
+	w.files = map[string]watchedFile{}
+	err = w.scan(time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+	go w.run(ctx)
+	return nil
+}
+
+// Close stops the watcher, an import which is running is cancelled and rolled back, its file is left where it is.
+func (w *ImportWatcher) Close() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	<-w.done
+}
+
+func (w *ImportWatcher) run(ctx context.Context) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			err := w.scan(now)
+			if err != nil {
+				// the directory may be remounted, it is scanned again at the next tick
+				continue
+			}
+			w.importStable(ctx, now)
+		}
+	}
+}
+
+// scan updates the state of the files of the directory, a new or a changed file becomes pending.
+func (w *ImportWatcher) scan(now time.Time) error {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return fmt.Errorf("read watched directory: %w", err)
+	}
+
+	found := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !isWatchedFile(name) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// the file has been removed since the directory was read
+			continue
+		}
+		found[name] = struct{}{}
+
+		file, seen := w.files[name]
+		if !seen || file.size != info.Size() || !file.modTime.Equal(info.ModTime()) {
+			w.files[name] = watchedFile{size: info.Size(), modTime: info.ModTime(), changedAt: now, pending: true}
+		}
+	}
+
+	for name := range w.files {
+		if _, ok := found[name]; !ok {
+			delete(w.files, name)
+		}
+	}
+	return nil
+}
+
+// importStable imports the pending files which have not changed for the debounce, one after the other.
+func (w *ImportWatcher) importStable(ctx context.Context, now time.Time) {
+	for name, file := range w.files {
+		if !file.pending || now.Sub(file.changedAt) < w.debounce {
+			continue
+		}
+
+		result := w.importFile(ctx, name)
+		if ctx.Err() != nil {
+			return
+		}
+		// a file which could not be moved stays where it is, it is imported again when it changes
+		file.pending = false
+		w.files[name] = file
+
+		w.mu.Lock()
+		hooks := w.imported
+		w.mu.Unlock()
+		for _, hook := range hooks {
+			hook(result)
+		}
+	}
+}
+
+// importFile imports the file and moves it with its report to the processed or the failed folder.
+func (w *ImportWatcher) importFile(ctx context.Context, name string) WatchedImport {
+	path := filepath.Join(w.dir, name)
+	if !filepath.IsAbs(path) {
+		// a relative path would be taken as relative to the data directory by SavePortsFromFile
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+	}
+
+	report, err := w.portService.SavePortsFromFile(ctx, path, nil, ImportOptions{Mode: w.mode})
+	result := WatchedImport{File: name, Report: report, Err: err}
+	if ctx.Err() != nil {
+		return result
+	}
+
+	folder := WatchProcessedDir
+	if err != nil {
+		folder = WatchFailedDir
+		result.Error = err.Error()
+	}
+
+	target, moveErr := w.move(path, folder)
+	if moveErr != nil {
+		result.Err = errors.Join(err, moveErr)
+		return result
+	}
+	result.Path = target
+
+	reportErr := writeWatchReport(target+watchReportSuffix, result)
+	if reportErr != nil {
+		result.Err = errors.Join(err, reportErr)
+	}
+	return result
+}
+
+// move moves the file to the folder of the watched directory, a file of the same name already in the folder
+// is kept and the moved file is prefixed with the time.
+func (w *ImportWatcher) move(path, folder string) (string, error) {
+	dir := filepath.Join(w.dir, folder)
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return "", fmt.Errorf("create %s folder: %w", folder, err)
+	}
+
+	target := filepath.Join(dir, filepath.Base(path))
+	if _, err := os.Stat(target); err == nil {
+		target = filepath.Join(dir, time.Now().UTC().Format("20060102T150405.000Z")+"-"+filepath.Base(path))
+	}
+
+	err = os.Rename(path, target)
+	if err != nil {
+		return "", fmt.Errorf("move file to %s folder: %w", folder, err)
+	}
+	return target, nil
+}
+
+func writeWatchReport(path string, result WatchedImport) error {
+	body, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode import report: %w", err)
+	}
+
+	err = os.WriteFile(path, body, 0o644) //nolint:gosec // the report is as readable as the imported file
+	if err != nil {
+		return fmt.Errorf("write import report: %w", err)
+	}
+	return nil
+}
+
+// isWatchedFile tells whether the file is a port file, i.e. a file of an import format, a compressed one or
+// a zip archive. The hidden files, like the temporary files of rsync, are not.
+func isWatchedFile(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+
+	ext := strings.ToLower(filepath.Ext(trimCompressionExtension(name)))
+	if ext == ".zip" {
+		return true
+	}
+	_, found := importFormatsByExtension[ext]
+	return found
+}
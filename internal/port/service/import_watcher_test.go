@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fir1/port/config"
+	"github.com/fir1/port/internal/port/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const watcherTestPorts = `{
+  "AEAJM": {"name": "Ajman", "city": "Ajman", "country": "United Arab Emirates", "unlocs": ["AEAJM"]},
+  "AEAUH": {"name": "Abu Dhabi", "city": "Abu Dhabi", "country": "United Arab Emirates", "unlocs": ["AEAUH"]}
+}`
+
+func TestImportWatcher(t *testing.T) {
+	dataDir := t.TempDir()
+	dir := filepath.Join(dataDir, "inbox")
+	// the files of the data directory are never imported, the watched directory is created when it is missing
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "ports.json"), []byte(watcherTestPorts), 0o600))
+
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+	watcher := NewImportWatcher(portService, config.Config{
+		DataDir: dataDir, ImportWatchDir: "inbox",
+		ImportWatchInterval: 10 * time.Millisecond, ImportWatchDebounce: 200 * time.Millisecond,
+	})
+
+	var (
+		mu      sync.Mutex
+		results []WatchedImport
+	)
+	watcher.OnImported(func(result WatchedImport) {
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, result)
+	})
+	require.NoError(t, watcher.Start())
+	defer watcher.Close()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ports.db"), []byte("database"), 0o600))
+
+	// the file is written in two steps, it is only imported once it has stopped changing
+	refreshed := filepath.Join(dir, "refreshed.json")
+	require.NoError(t, os.WriteFile(refreshed, []byte(watcherTestPorts[:100]), 0o600))
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, os.WriteFile(refreshed, []byte(watcherTestPorts), 0o600))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.ndjson"), []byte(`{"code": "AEAJM", "port": {`), 0o600))
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(results) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	byFile := map[string]WatchedImport{}
+	for _, result := range results {
+		byFile[result.File] = result
+	}
+	mu.Unlock()
+
+	processed := byFile["refreshed.json"]
+	require.NoError(t, processed.Err)
+	assert.Equal(t, 2, processed.Report.Created)
+	assert.Equal(t, filepath.Join(dir, WatchProcessedDir, "refreshed.json"), processed.Path)
+	assert.FileExists(t, processed.Path)
+	assert.NoFileExists(t, refreshed)
+
+	body, err := os.ReadFile(processed.Path + watchReportSuffix)
+	require.NoError(t, err)
+	var report WatchedImport
+	require.NoError(t, json.Unmarshal(body, &report))
+	assert.Equal(t, "refreshed.json", report.File)
+	assert.Equal(t, 2, report.Report.Created)
+	assert.Empty(t, report.Error)
+
+	failed := byFile["broken.ndjson"]
+	assert.ErrorAs(t, failed.Err, &ErrInvalidRecord{})
+	assert.True(t, failed.Report.RolledBack)
+	assert.Equal(t, filepath.Join(dir, WatchFailedDir, "broken.ndjson"), failed.Path)
+
+	body, err = os.ReadFile(failed.Path + watchReportSuffix)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(body, &report))
+	assert.NotEmpty(t, report.Error)
+
+	assert.FileExists(t, filepath.Join(dataDir, "ports.json"))
+	assert.FileExists(t, filepath.Join(dir, "ports.db"))
+
+	ports, err := portService.ListPorts(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, ports, 2)
+}
+
+func TestImportWatcher_StartsWithExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ports.json"), []byte(watcherTestPorts), 0o600))
+
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+	watcher := NewImportWatcher(portService, config.Config{
+		DataDir: t.TempDir(), ImportWatchDir: dir, ImportWatchInterval: 10 * time.Millisecond,
+	})
+	imported := make(chan WatchedImport, 1)
+	watcher.OnImported(func(result WatchedImport) {
+		imported <- result
+	})
+	require.NoError(t, watcher.Start())
+	defer watcher.Close()
+
+	// the files dropped while the service was down are imported when it starts
+	select {
+	case result := <-imported:
+		require.NoError(t, result.Err)
+		assert.Equal(t, filepath.Join(dir, WatchProcessedDir, "ports.json"), result.Path)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "the file has not been imported")
+	}
+}
+
+func TestImportWatcher_InvalidConfig(t *testing.T) {
+	dataDir := t.TempDir()
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+
+	watcher := NewImportWatcher(portService, config.Config{
+		DataDir: dataDir, ImportWatchDir: "inbox", ImportWatchInterval: time.Second, ImportWatchMode: "partial",
+	})
+	err := watcher.Start()
+	assert.ErrorAs(t, err, &ErrInvalidQuery{})
+	watcher.Close()
+
+	// the data directory holds the files read by the service, like the ports.json of POST /ports/from-file
+	for _, dir := range []string{"", ".", dataDir} {
+		watcher = NewImportWatcher(portService, config.Config{
+			DataDir: dataDir, ImportWatchDir: dir, ImportWatchInterval: time.Second,
+		})
+		assert.Error(t, watcher.Start(), dir)
+		watcher.Close()
+	}
+}
+
+func TestIsWatchedFile(t *testing.T) {
+	for name, watched := range map[string]bool{
+		"ports.json":      true,
+		"ports.JSONL":     true,
+		"ports.csv.gz":    true,
+		"ports.tsv.zst":   true,
+		"ports.zip":       true,
+		".ports.json.tmp": false,
+		".ports.json":     false,
+		"ports.db":        false,
+		"ports.db-wal":    false,
+		"portdb":          false,
+		"ports.json.part": false,
+	} {
+		assert.Equal(t, watched, isWatchedFile(name), name)
+	}
+}
@@ -2,10 +2,73 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/fir1/port/internal/port/model"
 )
 
+const (
+	// DefaultPageLimit is the number of ports returned when the page limit is not provided.
+	DefaultPageLimit = 100
+	// MaxPageLimit is the maximum number of ports which can be returned in a single page.
+	MaxPageLimit = 1000
+)
+
 func (s PortService) ListPorts(ctx context.Context) (map[string]model.Port, error) {
 	return s.repository.ListAll(ctx)
 }
+
+// ListPortsPage returns a single page of the ports which match the filter,
+// the page request is validated and defaults are applied.
+func (s PortService) ListPortsPage(ctx context.Context, filter model.PortFilter, page model.PageRequest) (model.Page, error) {
+	page, err := validatePageRequest(page)
+	if err != nil {
+		return model.Page{}, err
+	}
+
+	filter = model.PortFilter{
+		Country:    strings.TrimSpace(filter.Country),
+		Province:   strings.TrimSpace(filter.Province),
+		City:       strings.TrimSpace(filter.City),
+		Timezone:   strings.TrimSpace(filter.Timezone),
+		Unloc:      strings.TrimSpace(filter.Unloc),
+		NamePrefix: strings.TrimSpace(filter.NamePrefix),
+	}
+	if filter.IsEmpty() {
+		return s.repository.ListPage(ctx, page)
+	}
+	return s.repository.Find(ctx, filter, page)
+}
+
+func validatePageRequest(page model.PageRequest) (model.PageRequest, error) {
+	var reasons []string
+
+	switch {
+	case page.Limit == 0:
+		page.Limit = DefaultPageLimit
+	case page.Limit < 0 || page.Limit > MaxPageLimit:
+		reasons = append(reasons, fmt.Sprintf("limit must be between 1 and %d", MaxPageLimit))
+	}
+
+	if page.Offset < 0 {
+		reasons = append(reasons, "offset must not be negative")
+	}
+	if page.Offset > 0 && page.Cursor != "" {
+		reasons = append(reasons, "offset and cursor can not be used together")
+	}
+
+	if page.Sort == "" {
+		page.Sort = model.SortByUnlocode
+	}
+	switch field, _ := page.SortField(); field {
+	case model.SortByUnlocode, model.SortByCode, model.SortByName, model.SortByCountry:
+	default:
+		reasons = append(reasons, fmt.Sprintf("can not sort by %q, use one of unlocode, code, name, country", page.Sort))
+	}
+
+	if len(reasons) > 0 {
+		return model.PageRequest{}, ErrInvalidQuery{Reasons: reasons}
+	}
+	return page, nil
+}
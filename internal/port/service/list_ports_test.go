@@ -0,0 +1,60 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePageRequest(t *testing.T) {
+	testCases := []struct {
+		name          string
+		page          model.PageRequest
+		expectedPage  model.PageRequest
+		expectedError bool
+	}{
+		{
+			name:         "Defaults",
+			page:         model.PageRequest{},
+			expectedPage: model.PageRequest{Limit: DefaultPageLimit, Sort: model.SortByUnlocode},
+		},
+		{
+			name:         "DescendingSort",
+			page:         model.PageRequest{Limit: 10, Offset: 20, Sort: "-name"},
+			expectedPage: model.PageRequest{Limit: 10, Offset: 20, Sort: "-name"},
+		},
+		{
+			name:          "FailureLimitTooBig",
+			page:          model.PageRequest{Limit: MaxPageLimit + 1},
+			expectedError: true,
+		},
+		{
+			name:          "FailureNegativeOffset",
+			page:          model.PageRequest{Offset: -1},
+			expectedError: true,
+		},
+		{
+			name:          "FailureOffsetWithCursor",
+			page:          model.PageRequest{Offset: 10, Cursor: "cursor"},
+			expectedError: true,
+		},
+		{
+			name:          "FailureUnknownSort",
+			page:          model.PageRequest{Sort: "timezone"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := validatePageRequest(tc.page)
+			if tc.expectedError {
+				assert.ErrorAs(t, err, &ErrInvalidQuery{})
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedPage, page)
+		})
+	}
+}
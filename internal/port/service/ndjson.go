@@ -0,0 +1,59 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ndjsonRecord is a line of an NDJSON file.
+type ndjsonRecord struct {
+	Code string          `json:"code"`
+	Port json.RawMessage `json:"port"`
+}
+
+// NDJSONDecoder decodes a newline-delimited JSON file which holds a `{"code": ..., "port": {...}}` object
+// on every line. The lines are independent, so a line which can not be decoded is skipped as an invalid record.
+// The record of a port is its line number, the empty lines are ignored.
+type NDJSONDecoder struct{}
+
+func (NDJSONDecoder) Decode(ctx context.Context, file io.Reader, emit func(Entry)) {
+	reader := bufio.NewReader(file)
+
+	for line := 1; ctx.Err() == nil; line++ {
+		// a line is read as a whole whatever its length, unlike with a bufio.Scanner
+		data, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			emit(Entry{Error: fmt.Errorf("read line %d: %w", line, err)})
+			return
+		}
+
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			emit(decodeNDJSONRecord(line, data))
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func decodeNDJSONRecord(line int, data []byte) Entry {
+	var record ndjsonRecord
+	err := json.Unmarshal(data, &record)
+	if err == nil && record.Code == "" {
+		err = errors.New(`the "code" of the port is missing`)
+	}
+	if err == nil && len(record.Port) == 0 {
+		err = errors.New(`the "port" is missing`)
+	}
+	if err != nil {
+		return Entry{PortCode: record.Code, Record: line, Error: ErrInvalidRecord{Record: line, PortCode: record.Code, Err: err}}
+	}
+
+	return decodePort(line, record.Code, record.Port)
+}
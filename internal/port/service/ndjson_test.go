@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fir1/port/config"
+	"github.com/fir1/port/internal/port/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNDJSONDecoder(t *testing.T) {
+	data := `{"code": "AEAJM", "port": {"name": "Ajman", "city": "Ajman", "unlocs": ["AEAJM"]}}
+
+{"code": "AEAUH", "port": {"name": 1}}
+{"code": "AEDXB", "port": {"name": "Dubai"
+{"port": {"name": "Fujairah"}}
+   {"code": "AEKLF", "port": {"name": "Khor al Fakkan", "unlocs": ["AEKLF"]}}`
+
+	stream := NewStream(NDJSONDecoder{})
+	go stream.Start(strings.NewReader(data))
+
+	var entries []Entry
+	for entry := range stream.Watch() {
+		entries = append(entries, entry)
+	}
+
+	require.Len(t, entries, 5)
+	assert.NoError(t, entries[0].Error)
+	assert.Equal(t, "AEAJM", entries[0].PortCode)
+	assert.Equal(t, "Ajman", entries[0].Port.Name)
+	assert.Equal(t, 1, entries[0].Record)
+
+	// the empty line is counted, so the record is the line of the port
+	for i, line := range []int{3, 4, 5} {
+		entry := entries[i+1]
+		assert.Equal(t, line, entry.Record)
+		var invalidErr ErrInvalidRecord
+		require.ErrorAs(t, entry.Error, &invalidErr, "line %d", line)
+		assert.Equal(t, line, invalidErr.Record)
+	}
+	assert.Equal(t, "AEAUH", entries[1].PortCode)
+	assert.Contains(t, entries[3].Error.Error(), `"code" of the port is missing`)
+
+	// the line after the invalid ones is decoded, the last line has no newline
+	assert.NoError(t, entries[4].Error)
+	assert.Equal(t, "AEKLF", entries[4].PortCode)
+	assert.Equal(t, 6, entries[4].Record)
+}
+
+func TestDetectImportFormat(t *testing.T) {
+	testCases := []struct {
+		contentType string
+		fileName    string
+		expected    ImportFormat
+	}{
+		{"application/json", "ports.ndjson", ImportFormatJSON},
+		{"application/x-ndjson; charset=utf-8", "ports.json", ImportFormatNDJSON},
+		{"application/jsonl", "", ImportFormatNDJSON},
+		{"application/octet-stream", "ports.NDJSON", ImportFormatNDJSON},
+		{"", "ports.jsonl", ImportFormatNDJSON},
+		{"", "ports.json", ImportFormatJSON},
+		{"", "ports", ImportFormatJSON},
+		{"application/gzip", "ports.csv.gz", ImportFormatCSV},
+		{"", "ports.NDJSON.zst", ImportFormatNDJSON},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, DetectImportFormat(tc.contentType, tc.fileName), "%q %q", tc.contentType, tc.fileName)
+	}
+}
+
+func TestSavePortsFromFile_NDJSON(t *testing.T) {
+	path := writeTestFile(t, `{"code": "AEAJM", "port": {"name": "Ajman", "city": "Ajman", "country": "United Arab Emirates", "unlocs": ["AEAJM"]}}
+{"code": "AEDXB", "port": {"name": "Dubai", "city": "Dubai", "country": "United Arab Emirates", "unlocs": ["AEDXB"]}}
+`)
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+	ctx := context.Background()
+
+	// the file is told by the format as its name does not end with .ndjson
+	report, err := portService.SavePortsFromFile(ctx, path, nil, ImportOptions{Format: ImportFormatNDJSON})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+
+	port, err := portService.GetPort(ctx, "AEDXB")
+	require.NoError(t, err)
+	assert.Equal(t, "Dubai", port.Name)
+
+	_, err = portService.SavePortsFromFile(ctx, path, nil, ImportOptions{Format: ImportFormatJSON})
+	assert.Error(t, err, "an NDJSON file is not a JSON object")
+
+	_, err = portService.SavePortsFromFile(ctx, path, nil, ImportOptions{Format: "xml"})
+	assert.ErrorAs(t, err, &ErrInvalidQuery{})
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/pkg/geo"
+)
+
+const (
+	// DefaultNearbyRadiusKm is the search radius used when the radius is not provided.
+	DefaultNearbyRadiusKm = 100
+	// DefaultNearbyLimit is the number of ports returned when the limit is not provided.
+	DefaultNearbyLimit = 10
+)
+
+// NearbyPorts returns the ports within the radius around the location sorted by their great-circle distance.
+func (s PortService) NearbyPorts(ctx context.Context, location geo.Point, radiusKm float64, limit int) ([]model.NearbyPort, error) {
+	var reasons []string
+	if !location.Valid() {
+		reasons = append(reasons, "lat must be between -90 and 90, lon must be between -180 and 180")
+	}
+
+	switch {
+	case radiusKm == 0:
+		radiusKm = DefaultNearbyRadiusKm
+	case radiusKm < 0 || radiusKm > geo.MaxDistanceKm:
+		reasons = append(reasons, fmt.Sprintf("radius_km must be between 0 and %.0f", geo.MaxDistanceKm))
+	}
+
+	switch {
+	case limit == 0:
+		limit = DefaultNearbyLimit
+	case limit < 0 || limit > MaxPageLimit:
+		reasons = append(reasons, fmt.Sprintf("limit must be between 1 and %d", MaxPageLimit))
+	}
+
+	if len(reasons) > 0 {
+		return nil, ErrInvalidQuery{Reasons: reasons}
+	}
+	return s.repository.Nearby(ctx, location, radiusKm, limit)
+}
@@ -1,9 +1,11 @@
 package service
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"os"
 	"path/filepath"
@@ -12,84 +14,41 @@ import (
 
 	"github.com/fir1/port/internal/port/model"
 	"github.com/fir1/port/internal/port/repository"
+	"github.com/fir1/port/internal/port/validation"
 )
 
-func (s PortService) SavePortsFromFile(ctx context.Context, filePath string, file multipart.File) error {
+// SavePortsFromFile imports the ports of the file into the repository and reports what has been done.
+// In the strict mode the import stops at the first record which can not be decoded or does not pass the validation,
+// in the lenient mode such records are skipped and listed in the report. Errors of the file itself and of
+// the repository stop the import in both modes. The ports are written in a single transaction, so a stopped import
+// leaves the stored ports untouched and the readers never see a partly imported file. The report is returned with
+// the error too. A dry run goes through the same steps, but it only reports the diff of every port instead of
+// writing it.
+func (s PortService) SavePortsFromFile(ctx context.Context, filePath string, file multipart.File, opts ImportOptions) (ImportReport, error) {
 	// Check if both filePath and file are provided
 	if filePath != "" && file != nil {
-		return errors.New("both filePath and file cannot be provided at the same time")
+		return ImportReport{}, errors.New("both filePath and file cannot be provided at the same time")
 	}
 
 	// Check if neither filePath nor file are provided
 	if filePath == "" && file == nil {
-		return errors.New("either filePath or file must be provided")
+		return ImportReport{}, errors.New("either filePath or file must be provided")
 	}
 
-	// Create a cancel context and obtain a cancel function
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	errChan := make(chan error)
-	var wg sync.WaitGroup
-
-	jsonStream := NewJSONStream()
-
-	// Use a worker pool to handle port processing goroutines
-	numWorkers := runtime.NumCPU()
-	workerPool := make(chan struct{}, numWorkers)
-
-	wg.Add(1)
-	go func(wg *sync.WaitGroup) {
-		defer wg.Done()
-
-		for data := range jsonStream.Watch() {
-			if data.Error != nil {
-				// If there is an error in JSON stream, cancel the context to stop further processing
-				cancel()
-				errChan <- data.Error
-				return
-			}
+	mode, err := opts.mode()
+	if err != nil {
+		return ImportReport{}, err
+	}
 
-			workerPool <- struct{}{} // Acquire a worker slot from the pool
-			wg.Add(1)
-			go func(ctx context.Context, wg *sync.WaitGroup, id string, p model.Port) {
-				defer wg.Done()
-				defer func() {
-					<-workerPool
-				}() // Release the worker slot when done processing
-
-				// Check if the cancel signal has been received
-				select {
-				case <-ctx.Done():
-					return
-				default:
-				}
-
-				// Check if the port already exists in the DB
-				_, err := s.repository.Get(ctx, id)
-				switch {
-				case err == nil:
-					err = s.repository.Update(ctx, id, p)
-					if err != nil {
-						errChan <- fmt.Errorf("error updating port with ID %s: %w", id, err)
-						return
-					}
-				case errors.As(err, &repository.ErrObjectNotFound{}):
-					// If not found, create a new record in the DB
-					err = s.repository.Create(ctx, id, p)
-					if err != nil {
-						errChan <- fmt.Errorf("error creating port with ID %s: %w", id, err)
-						return
-					}
-				default:
-					errChan <- fmt.Errorf("error getting port with ID %s: %w", id, err)
-					return
-				}
-			}(ctx, wg, data.PortCode, data.Port)
-		}
-	}(&wg)
+	if opts.Format == "" && filePath != "" {
+		opts.Format = DetectImportFormat("", filePath)
+	}
+	// the format is checked before the file is opened
+	_, err = opts.Format.decoder(s.config)
+	if err != nil {
+		return ImportReport{}, err
+	}
 
-	var err error
 	if filePath != "" {
 		if !filepath.IsAbs(filePath) {
 			filePath = fmt.Sprintf("%s/%s", s.config.DataDir, filePath)
@@ -97,20 +56,225 @@ func (s PortService) SavePortsFromFile(ctx context.Context, filePath string, fil
 
 		file, err = os.Open(filePath)
 		if err != nil {
-			return fmt.Errorf("open file: %w", err)
+			return ImportReport{}, fmt.Errorf("open file: %w", err)
+		}
+		defer file.Close()
+	}
+
+	reader, decoder, release, err := s.importReader(file, opts.Format, opts.Progress)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	defer release()
+
+	return s.importPorts(ctx, reader, decoder, mode, opts)
+}
+
+// SavePortsFromStream imports the ports read from the stream like SavePortsFromFile does. The stream is read once
+// from the start to the end, so it can be the body of a request. The encoding is the Content-Encoding of the stream:
+// a gzip or a zstd stream is decompressed as it is read, a stream without the encoding is decompressed when its
+// first bytes tell the compression. A zip archive can only be imported from a file. The format is opts.Format,
+// ImportFormatJSON when it is empty. The options and the encoding are checked before the stream is read.
+func (s PortService) SavePortsFromStream(ctx context.Context, stream io.Reader, encoding string, opts ImportOptions) (ImportReport, error) {
+	mode, err := opts.mode()
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	decoder, err := opts.Format.decoder(s.config)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	reader := opts.Progress.reader(stream)
+	if encoding == "" {
+		buffered := bufio.NewReader(reader)
+		encoding, err = sniffEncoding(buffered)
+		if err != nil {
+			return ImportReport{}, err
 		}
+		reader = buffered
 	}
 
-	jsonStream.Start(file)
+	reader, release, err := decodeContent(reader, encoding)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	defer release()
 
-	// Start a goroutine to close the error channel after all goroutines have completed
+	return s.importPorts(ctx, reader, decoder, mode, opts)
+}
+
+// importPorts imports the ports of the reader, or only reports their diff for a dry run.
+func (s PortService) importPorts(ctx context.Context, reader io.Reader, decoder Decoder, mode ImportMode, opts ImportOptions) (ImportReport, error) {
+	if opts.DryRun {
+		return s.previewPorts(ctx, reader, decoder, mode, opts)
+	}
+
+	report := newReportBuilder(mode, false)
+	report.report.Sync = opts.Sync
+
+	tx, err := s.repository.Begin(ctx)
+	if err != nil {
+		return report.finish(), fmt.Errorf("begin transaction: %w", err)
+	}
+
+	// the ports of the file are collected for a sync only
+	var seen *keySet
+	if opts.Sync {
+		seen = &keySet{keys: map[string]struct{}{}}
+	}
+
+	err = s.streamPorts(ctx, reader, decoder, mode, report, opts.Progress, func(ctx context.Context, entry Entry) error {
+		seen.add(entry.PortCode)
+
+		outcome, err := s.importPort(ctx, tx, entry.PortCode, entry.Port)
+		if err != nil {
+			return err
+		}
+		report.add(outcome)
+		return nil
+	})
+	if err == nil && opts.Sync {
+		err = s.deleteMissingPorts(ctx, tx, seen, report)
+	}
+	if err == nil {
+		err = tx.Commit()
+		if err != nil {
+			err = fmt.Errorf("commit transaction: %w", err)
+		}
+	}
+	if err != nil {
+		report.rolledBack()
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			err = fmt.Errorf("%w; rollback: %v", err, rollbackErr)
+		}
+		return report.finish(), err
+	}
+
+	return report.finish(), nil
+}
+
+// streamPorts streams the ports of the file decoded by the decoder to the process function, which is called
+// concurrently by the workers for every valid record. The invalid records are added to the report, in the strict mode
+// the first of them stops the stream like an error of the file or of the process function does. Every record read,
+// processed or skipped is counted by the progress as it happens.
+func (s PortService) streamPorts(ctx context.Context, file io.Reader, decoder Decoder, mode ImportMode,
+	report *reportBuilder, progress *ImportProgress, process func(ctx context.Context, entry Entry) error) error {
+	// Create a cancel context and obtain a cancel function
+	importCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the first error which stops the import, the context is cancelled so the workers stop too
+	var (
+		importErr error
+		failOnce  sync.Once
+	)
+	fail := func(err error) {
+		failOnce.Do(func() {
+			importErr = err
+			cancel()
+		})
+	}
+
+	// the stream stops reading the file as soon as the import is stopped, it is waited for, so the file
+	// is not read anymore when the import returns
+	stream := NewStream(decoder).WithHooks(StreamHooks{Record: progress.recordDecoded})
+	streamDone := make(chan struct{})
 	go func() {
-		wg.Wait()
-		close(errChan)
+		defer close(streamDone)
+		stream.StartContext(importCtx, file)
 	}()
 
-	for err := range errChan {
-		return err
+	// Use a worker pool to handle port processing goroutines
+	workerPool := make(chan struct{}, runtime.NumCPU())
+	var wg sync.WaitGroup
+
+	for entry := range stream.Watch() {
+		if importCtx.Err() != nil {
+			break
+		}
+
+		err := entry.Error
+		if err != nil && !errors.As(err, &ErrInvalidRecord{}) {
+			fail(err)
+			continue
+		}
+		if err == nil {
+			err = validation.ValidatePort(entry.PortCode, entry.Port)
+		}
+		if err != nil {
+			report.skip(entry, err)
+			progress.recordSkipped(entry, err)
+			if mode == ImportModeStrict {
+				fail(err)
+			}
+			continue
+		}
+
+		workerPool <- struct{}{} // Acquire a worker slot from the pool
+		wg.Add(1)
+		go func(entry Entry) {
+			defer wg.Done()
+			defer func() {
+				<-workerPool
+			}() // Release the worker slot when done processing
+
+			// Check if the cancel signal has been received
+			if importCtx.Err() != nil {
+				return
+			}
+
+			err := process(importCtx, entry)
+			if err != nil {
+				fail(err)
+				return
+			}
+			progress.recordWritten()
+		}(entry)
+	}
+	wg.Wait()
+	cancel()
+	<-streamDone
+
+	if importErr == nil {
+		importErr = ctx.Err()
+	}
+	if importErr == nil && file != nil {
+		// the decoders may stop before the end of the file, it is read to the end so the errors found there,
+		// like a wrong checksum of a download or of a gzip stream, stop the import too
+		_, err := io.Copy(io.Discard, file)
+		if err != nil {
+			importErr = fmt.Errorf("read the end of the file: %w", err)
+		}
+	}
+	return importErr
+}
+
+// importPort creates the port or updates it when it already exists, the port is not written when it has not changed.
+func (s PortService) importPort(ctx context.Context, tx repository.Transaction, id string, port model.Port) (importOutcome, error) {
+	// Check if the port already exists in the DB
+	stored, err := tx.Get(ctx, id)
+	switch {
+	case err == nil:
+		if samePort(stored, port) {
+			return outcomeUnchanged, nil
+		}
+
+		err = tx.Update(ctx, id, port)
+		if err != nil {
+			return 0, fmt.Errorf("error updating port with ID %s: %w", id, err)
+		}
+		return outcomeUpdated, nil
+	case errors.As(err, &repository.ErrObjectNotFound{}):
+		// If not found, create a new record in the DB
+		err = tx.Create(ctx, id, port)
+		if err != nil {
+			return 0, fmt.Errorf("error creating port with ID %s: %w", id, err)
+		}
+		return outcomeCreated, nil
+	default:
+		return 0, fmt.Errorf("error getting port with ID %s: %w", id, err)
 	}
-	return nil
 }
@@ -7,12 +7,14 @@ import (
 	"mime/multipart"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/fir1/port/config"
 	"github.com/fir1/port/internal/port/model"
 	"github.com/fir1/port/internal/port/repository"
+	"github.com/fir1/port/internal/port/validation"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -67,11 +69,12 @@ func TestSavePortsFromFile(t *testing.T) {
 
 	expectedPorts := map[string]model.Port{
 		"AEAJM": {
+			Version:     model.PortVersion,
 			Name:        "Ajman",
 			City:        "Ajman",
 			Country:     "United Arab Emirates",
-			Alias:       []interface{}{},
-			Regions:     []interface{}{},
+			Alias:       []string{},
+			Regions:     []model.Region{},
 			Coordinates: []float64{55.5136433, 25.4052165},
 			Province:    "Ajman",
 			Timezone:    "Asia/Dubai",
@@ -79,13 +82,14 @@ func TestSavePortsFromFile(t *testing.T) {
 			Code:        "52000",
 		},
 		"AEAUH": {
+			Version:     model.PortVersion,
 			Name:        "Abu Dhabi",
 			Coordinates: []float64{54.37, 24.47},
 			City:        "Abu Dhabi",
 			Province:    "Abu Z¸aby [Abu Dhabi]",
 			Country:     "United Arab Emirates",
-			Alias:       []interface{}{},
-			Regions:     []interface{}{},
+			Alias:       []string{},
+			Regions:     []model.Region{},
 			Timezone:    "Asia/Dubai",
 			Unlocs:      []string{"AEAUH"},
 			Code:        "52001",
@@ -115,11 +119,12 @@ func TestSavePortsFromFile(t *testing.T) {
 			expectedError: nil,
 			expectedPorts: map[string]model.Port{
 				"AEAJM": {
+					Version:     model.PortVersion,
 					Name:        "Ajman",
 					City:        "Ajman",
 					Country:     "United Arab Emirates",
-					Alias:       []interface{}{},
-					Regions:     []interface{}{},
+					Alias:       []string{},
+					Regions:     []model.Region{},
 					Coordinates: []float64{55.5136433, 25.4052165},
 					Province:    "Ajman",
 					Timezone:    "Asia/Dubai",
@@ -127,13 +132,14 @@ func TestSavePortsFromFile(t *testing.T) {
 					Code:        "52000",
 				},
 				"AEAUH": {
+					Version:     model.PortVersion,
 					Name:        "Abu Dhabi",
 					Coordinates: []float64{54.37, 24.47},
 					City:        "Abu Dhabi",
 					Province:    "Abu Z¸aby [Abu Dhabi]",
 					Country:     "United Arab Emirates",
-					Alias:       []interface{}{},
-					Regions:     []interface{}{},
+					Alias:       []string{},
+					Regions:     []model.Region{},
 					Timezone:    "Asia/Dubai",
 					Unlocs:      []string{"AEAUH"},
 					Code:        "52001",
@@ -156,7 +162,7 @@ func TestSavePortsFromFile(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			err = portService.SavePortsFromFile(ctx, tc.fileName, tc.file)
+			_, err = portService.SavePortsFromFile(ctx, tc.fileName, tc.file, ImportOptions{})
 			if tc.expectedError != nil {
 				assert.Equal(t, tc.expectedError, err)
 			} else {
@@ -178,3 +184,287 @@ func TestSavePortsFromFile(t *testing.T) {
 		})
 	}
 }
+
+func TestSavePortsFromFile_InvalidPort(t *testing.T) {
+	data := `{
+  "AEAJM": {"name": "Ajman", "city": "Ajman", "country": "United Arab Emirates", "unlocs": ["AEAJM"]},
+  "AEAUH": {"name": "Abu Dhabi", "city": "Abu Dhabi", "country": "United Arab Emirates", "unlocs": ["AEAJM"],
+    "timezone": "Asia/Abu_Dhabi", "coordinates": [54.37]},
+  "AEDXB": {"name": "Dubai", "city": "Dubai", "country": "United Arab Emirates", "unlocs": ["AEDXB"]}
+}`
+	tmpfile, err := os.CreateTemp(t.TempDir(), "ports.json")
+	require.NoError(t, err)
+	_, err = tmpfile.WriteString(data)
+	require.NoError(t, err)
+	require.NoError(t, tmpfile.Close())
+
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	report, err := portService.SavePortsFromFile(ctx, tmpfile.Name(), nil, ImportOptions{})
+
+	var validationErr validation.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "AEAUH", validationErr.Unlocode)
+	assert.Equal(t, []validation.Violation{
+		{Field: "unlocs", Message: "must contain the port UN/LOCODE AEAUH"},
+		{Field: "coordinates", Message: "must contain exactly two values [lon, lat], got 1"},
+		{Field: "timezone", Message: `"Asia/Abu_Dhabi" is not a valid IANA time zone`},
+	}, validationErr.Violations)
+
+	assert.Equal(t, ImportModeStrict, report.Mode)
+	assert.True(t, report.RolledBack)
+	assert.Equal(t, 1, report.Skipped)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, "AEAUH", report.Errors[0].Unlocode)
+	assert.Equal(t, 2, report.Errors[0].Record)
+	assert.Equal(t, validationErr.Violations, report.Errors[0].Violations)
+
+	// the import is all or nothing, so the valid ports are not stored either
+	ports, err := portService.ListPorts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, ports)
+}
+
+func TestSavePortsFromFile_Lenient(t *testing.T) {
+	first := writeTestFile(t, `{
+  "AEAJM": {"name": "Ajman", "city": "Ajman", "country": "United Arab Emirates", "unlocs": ["AEAJM"]},
+  "AEAUH": {"name": "Abu Dhabi", "city": "Abu Dhabi", "country": "United Arab Emirates", "unlocs": ["AEAJM"]},
+  "AEDXB": {"name": "Dubai", "city": "Dubai", "country": "United Arab Emirates", "unlocs": ["AEDXB"], "coordinates": "55.27,25.25"},
+  "AEFJR": {"name": "Fujairah", "city": "Fujairah", "country": "United Arab Emirates", "unlocs": ["AEFJR"]}
+}`)
+	second := writeTestFile(t, `{
+  "AEAJM": {"name": "Ajman", "city": "Ajman", "country": "United Arab Emirates", "unlocs": ["AEAJM"]},
+  "AEFJR": {"name": "Fujairah", "city": "Al Fujairah", "country": "United Arab Emirates", "unlocs": ["AEFJR"]},
+  "AEKLF": {"name": "Khor al Fakkan", "city": "Khor al Fakkan", "country": "United Arab Emirates", "unlocs": ["AEKLF"]}
+}`)
+
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	report, err := portService.SavePortsFromFile(ctx, first, nil, ImportOptions{Mode: ImportModeLenient})
+	require.NoError(t, err)
+	assert.Equal(t, ImportModeLenient, report.Mode)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 0, report.Updated)
+	assert.Equal(t, 0, report.Unchanged)
+	assert.Equal(t, 2, report.Skipped)
+	assert.False(t, report.ErrorsTruncated)
+	assert.False(t, report.FinishedAt.Before(report.StartedAt))
+
+	require.Len(t, report.Errors, 2)
+	assert.Equal(t, "AEAUH", report.Errors[0].Unlocode)
+	assert.Equal(t, 2, report.Errors[0].Record)
+	assert.Equal(t, []validation.Violation{
+		{Field: "unlocs", Message: "must contain the port UN/LOCODE AEAUH"},
+	}, report.Errors[0].Violations)
+	assert.Equal(t, "AEDXB", report.Errors[1].Unlocode)
+	assert.Equal(t, 3, report.Errors[1].Record)
+	assert.Contains(t, report.Errors[1].Message, "decode line 3")
+	assert.Empty(t, report.Errors[1].Violations)
+
+	_, err = portService.GetPort(ctx, "AEFJR")
+	assert.NoError(t, err, "the ports after the invalid records must be stored")
+
+	report, err = portService.SavePortsFromFile(ctx, second, nil, ImportOptions{Mode: ImportModeLenient})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 1, report.Unchanged)
+	assert.Equal(t, 0, report.Skipped)
+	assert.Empty(t, report.Errors)
+
+	port, err := portService.GetPort(ctx, "AEFJR")
+	require.NoError(t, err)
+	assert.Equal(t, "Al Fujairah", port.City)
+}
+
+func TestSavePortsFromFile_Progress(t *testing.T) {
+	path := writeTestFile(t, `{
+  "AEAJM": {"name": "Ajman", "city": "Ajman", "country": "United Arab Emirates", "unlocs": ["AEAJM"]},
+  "AEAUH": {"name": "Abu Dhabi", "city": "Abu Dhabi", "country": "United Arab Emirates", "unlocs": ["AEAJM"]},
+  "AEDXB": {"name": "Dubai", "city": "Dubai", "country": "United Arab Emirates", "unlocs": ["AEDXB"], "coordinates": "55.27,25.25"},
+  "AEFJR": {"name": "Fujairah", "city": "Fujairah", "country": "United Arab Emirates", "unlocs": ["AEFJR"]}
+}`)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+
+	progress := &ImportProgress{}
+	errs, unsubscribe := progress.Subscribe()
+	defer unsubscribe()
+
+	_, err = portService.SavePortsFromFile(context.Background(), path, nil, ImportOptions{Mode: ImportModeLenient, Progress: progress})
+	require.NoError(t, err)
+
+	assert.Equal(t, info.Size(), progress.Bytes())
+	assert.Equal(t, int64(4), progress.Decoded())
+	assert.Equal(t, int64(2), progress.Written())
+	assert.Equal(t, int64(2), progress.Skipped())
+	assert.Equal(t, int64(4), progress.Records())
+
+	// the errors are sent as the records are skipped, in the order of the file
+	require.Len(t, errs, 2)
+	recordErr := <-errs
+	assert.Equal(t, "AEAUH", recordErr.Unlocode)
+	assert.NotEmpty(t, recordErr.Violations)
+	recordErr = <-errs
+	assert.Equal(t, "AEDXB", recordErr.Unlocode)
+	assert.Equal(t, 3, recordErr.Record)
+
+	progress.close()
+	_, open := <-errs
+	assert.False(t, open, "the subscribers are closed when the import has finished")
+
+	lateErrs, _ := progress.Subscribe()
+	_, open = <-lateErrs
+	assert.False(t, open)
+}
+
+func TestSavePortsFromFile_InvalidMode(t *testing.T) {
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+
+	_, err := portService.SavePortsFromFile(context.Background(), "ports.json", nil, ImportOptions{Mode: "partial"})
+	assert.ErrorAs(t, err, &ErrInvalidQuery{})
+}
+
+func TestSavePortsFromFile_DryRun(t *testing.T) {
+	tmpfile, err := os.CreateTemp(t.TempDir(), "ports.json")
+	require.NoError(t, err)
+	_, err = tmpfile.WriteString(`{
+  "AEAJM": {"name": "Ajman", "city": "Ajman", "country": "United Arab Emirates", "unlocs": ["AEAJM"]},
+  "AEAUH": {"name": "Abu Dhabi", "city": "Abu Dhabi", "country": "United Arab Emirates", "unlocs": ["AEAUH"],
+    "timezone": "Asia/Dubai", "coordinates": [54.37, 24.47]},
+  "AEDXB": {"name": "Dubai", "city": "Dubai", "country": "United Arab Emirates", "unlocs": ["AEDXB"]}
+}`)
+	require.NoError(t, err)
+	require.NoError(t, tmpfile.Close())
+
+	repo := repository.NewPostRepositoryMemoryDB()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, repo.Create(ctx, "AEAJM", model.Port{
+		Version: model.PortVersion, Name: "Ajman", City: "Ajman", Country: "United Arab Emirates", Unlocs: []string{"AEAJM"},
+	}))
+	stored := model.Port{
+		Version:  model.PortVersion,
+		Name:     "Abu Dhabi",
+		City:     "Abu Dhabi",
+		Country:  "United Arab Emirates",
+		Province: "Abu Z¸aby [Abu Dhabi]",
+		Timezone: "Asia/Abu_Dhabi",
+		Unlocs:   []string{"AEAUH"},
+	}
+	require.NoError(t, repo.Create(ctx, "AEAUH", stored))
+
+	portService := NewPortService(repo, config.Config{})
+
+	report, err := portService.SavePortsFromFile(ctx, tmpfile.Name(), nil, ImportOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 1, report.Unchanged)
+
+	require.Len(t, report.Diff, 2)
+	assert.Equal(t, PortDiff{
+		Record:   2,
+		Unlocode: "AEAUH",
+		Action:   DiffActionChanged,
+		Changes: []FieldChange{
+			{Field: "coordinates", Type: FieldAdded, New: []float64{54.37, 24.47}},
+			{Field: "province", Type: FieldRemoved, Old: "Abu Z¸aby [Abu Dhabi]"},
+			{Field: "timezone", Type: FieldChanged, Old: "Asia/Abu_Dhabi", New: "Asia/Dubai"},
+		},
+	}, report.Diff[0])
+	assert.Equal(t, "AEDXB", report.Diff[1].Unlocode)
+	assert.Equal(t, DiffActionAdded, report.Diff[1].Action)
+	assert.Equal(t, []FieldChange{
+		{Field: "name", Type: FieldAdded, New: "Dubai"},
+		{Field: "city", Type: FieldAdded, New: "Dubai"},
+		{Field: "country", Type: FieldAdded, New: "United Arab Emirates"},
+		{Field: "unlocs", Type: FieldAdded, New: []string{"AEDXB"}},
+	}, report.Diff[1].Changes)
+
+	// nothing is written by a dry run
+	port, err := portService.GetPort(ctx, "AEAUH")
+	require.NoError(t, err)
+	assert.Equal(t, stored, port)
+	_, err = portService.GetPort(ctx, "AEDXB")
+	assert.ErrorAs(t, err, &repository.ErrObjectNotFound{})
+}
+
+func TestSavePortsFromFile_RollsBackOnFileError(t *testing.T) {
+	// the file breaks after the second port, when the first ports may already have been written
+	file := writeTestFile(t, `{
+  "AEAJM": {"name": "Ajman", "city": "Ajman", "country": "United Arab Emirates", "unlocs": ["AEAJM"]},
+  "AEDXB": {"name": "Dubai", "city": "Dubai", "country": "United Arab Emirates", "unlocs": ["AEDXB"]},
+  "AEFJR": {"name": "Fujairah", "city"`)
+
+	for _, mode := range []ImportMode{ImportModeStrict, ImportModeLenient} {
+		t.Run(string(mode), func(t *testing.T) {
+			portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			report, err := portService.SavePortsFromFile(ctx, file, nil, ImportOptions{Mode: mode})
+			require.Error(t, err)
+			assert.True(t, report.RolledBack)
+
+			ports, err := portService.ListPorts(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, ports)
+		})
+	}
+}
+
+// largeTestFile returns a JSON file of many ports, the first of them is invalid.
+func largeTestFile(t *testing.T, ports int) string {
+	var b strings.Builder
+	b.WriteString(`{"AAAAA": {"name": "", "unlocs": ["AAAAA"]}`)
+	for i := 1; i < ports; i++ {
+		code := fmt.Sprintf("AA%c%c%c", 'A'+i/676%26, 'A'+i/26%26, 'A'+i%26)
+		fmt.Fprintf(&b, `, %q: {"name": "Port %d", "city": "City", "country": "Country", "unlocs": [%q]}`, code, i, code)
+	}
+	b.WriteString("}")
+	return writeTestFile(t, b.String())
+}
+
+func TestSavePortsFromFile_StopsReading(t *testing.T) {
+	path := largeTestFile(t, 10000)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := map[string]struct {
+		ctx  context.Context
+		mode ImportMode
+	}{
+		"strict failure on the first record": {context.Background(), ImportModeStrict},
+		"cancelled before the import":        {cancelled, ImportModeLenient},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+			progress := &ImportProgress{}
+
+			report, err := portService.SavePortsFromFile(tc.ctx, path, nil, ImportOptions{Mode: tc.mode, Progress: progress})
+			require.Error(t, err)
+			assert.True(t, report.RolledBack)
+			// the file is read in chunks, only the first ones may have been read when the import stopped
+			assert.Less(t, progress.Bytes(), info.Size()/10, "the rest of the file must not be read")
+			assert.Less(t, progress.Decoded(), int64(1000))
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fir1/port/internal/port/model"
+)
+
+const (
+	// DefaultSearchLimit is the number of ports returned by the search and the autocomplete when the limit is not provided.
+	DefaultSearchLimit = 10
+	// MaxSearchLimit is the maximum number of ports returned by the search and the autocomplete.
+	MaxSearchLimit = 100
+)
+
+// SearchPorts returns the ports whose name, city, alias or province match the full-text query, the best match first.
+func (s PortService) SearchPorts(ctx context.Context, query string, limit int) ([]model.SearchResult, error) {
+	var reasons []string
+	query = strings.TrimSpace(query)
+	if query == "" {
+		reasons = append(reasons, "q is required")
+	}
+
+	switch {
+	case limit == 0:
+		limit = DefaultSearchLimit
+	case limit < 0 || limit > MaxSearchLimit:
+		reasons = append(reasons, fmt.Sprintf("limit must be between 1 and %d", MaxSearchLimit))
+	}
+
+	if len(reasons) > 0 {
+		return nil, ErrInvalidQuery{Reasons: reasons}
+	}
+	return s.repository.Search(ctx, query, limit)
+}
+
+// AutocompletePorts returns the ports whose name, city, alias or UN/LOCODE starts with the prefix,
+// exact matches first, then UN/LOCODE, name, city and alias matches.
+func (s PortService) AutocompletePorts(ctx context.Context, prefix string, limit int) ([]model.Suggestion, error) {
+	var reasons []string
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		reasons = append(reasons, "prefix is required")
+	}
+
+	switch {
+	case limit == 0:
+		limit = DefaultSearchLimit
+	case limit < 0 || limit > MaxSearchLimit:
+		reasons = append(reasons, fmt.Sprintf("limit must be between 1 and %d", MaxSearchLimit))
+	}
+
+	if len(reasons) > 0 {
+		return nil, ErrInvalidQuery{Reasons: reasons}
+	}
+	return s.repository.Autocomplete(ctx, prefix, limit)
+}
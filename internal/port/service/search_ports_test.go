@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fir1/port/config"
+	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/internal/port/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortService_SearchPorts(t *testing.T) {
+	ctx := context.Background()
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+	require.NoError(t, portService.repository.Create(ctx, "CNFOC", model.Port{
+		Name:  "Fuzhou",
+		City:  "Fuzhou",
+		Alias: []string{"Fuchou", "Foochow", "Foochou"},
+	}))
+
+	results, err := portService.SearchPorts(ctx, "  foochow ", 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "CNFOC", results[0].Unlocode)
+
+	_, err = portService.SearchPorts(ctx, " ", 0)
+	assert.ErrorAs(t, err, &ErrInvalidQuery{})
+
+	_, err = portService.SearchPorts(ctx, "fuzhou", MaxSearchLimit+1)
+	assert.ErrorAs(t, err, &ErrInvalidQuery{})
+}
+
+func TestPortService_AutocompletePorts(t *testing.T) {
+	ctx := context.Background()
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+	require.NoError(t, portService.repository.Create(ctx, "CNFOC", model.Port{
+		Name:   "Fuzhou",
+		City:   "Fuzhou",
+		Alias:  []string{"Fuchou", "Foochow", "Foochou"},
+		Unlocs: []string{"CNFOC"},
+	}))
+
+	suggestions, err := portService.AutocompletePorts(ctx, " cnf", 0)
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1)
+	assert.Equal(t, model.MatchTypeCode, suggestions[0].MatchType)
+
+	_, err = portService.AutocompletePorts(ctx, "", 0)
+	assert.ErrorAs(t, err, &ErrInvalidQuery{})
+
+	_, err = portService.AutocompletePorts(ctx, "fu", -1)
+	assert.ErrorAs(t, err, &ErrInvalidQuery{})
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,23 +10,60 @@ import (
 )
 
 // Entry represents each stream. If the stream fails, an error will be present.
+// A record which can not be decoded has an ErrInvalidRecord error, the stream goes on with the next record.
+// Any other error ends the stream.
 type Entry struct {
 	Error    error
 	PortCode string
 	Port     model.Port
+	// Record is the position of the port in the file, starting from 1.
+	Record int
+	// File is the name of the archive member the port has been read from, it is empty for a file which is not an archive.
+	File string
 }
 
 // Stream helps transmit each streams within a channel.
 type Stream struct {
-	stream chan Entry
+	stream  chan Entry
+	decoder Decoder
+	hooks   StreamHooks
 }
 
+// Decoder reads the ports of a file in one format for a Stream.
+type Decoder interface {
+	// Decode reads the records of the file one by one and passes each of them to emit as soon as it has been read.
+	// A record which can not be decoded is passed with an ErrInvalidRecord error and the decoder goes on with
+	// the next one when the format allows it, any other error is passed as the last entry. The decoder stops
+	// between the records when the context is done.
+	Decode(ctx context.Context, file io.Reader, emit func(Entry))
+}
+
+// StreamHooks are called by Start as it reads the file, before the entry is sent to the channel.
+// A nil hook is skipped.
+type StreamHooks struct {
+	// Record is called with every record read from the file, including the ones which can not be decoded.
+	Record func(Entry)
+}
+
+// NewJSONStream returns a stream of a file which holds the ports in a single JSON object keyed by their UN/LOCODE.
 func NewJSONStream() Stream {
+	return NewStream(JSONDecoder{})
+}
+
+// NewStream returns a stream of a file in the format of the decoder.
+func NewStream(decoder Decoder) Stream {
 	return Stream{
-		stream: make(chan Entry),
+		stream:  make(chan Entry),
+		decoder: decoder,
 	}
 }
 
+// WithHooks returns the stream with the hooks set, it must be called before Start.
+func (s Stream) WithHooks(hooks StreamHooks) Stream {
+	s.hooks = hooks
+	return s
+}
+
 // Watch watches JSON streams. Each stream entry will either have an error or a
 // Post object. Client code does not need to explicitly exit after catching an
 // error as the `Start` method will close the channel automatically.
@@ -33,58 +71,103 @@ func (s Stream) Watch() <-chan Entry {
 	return s.stream
 }
 
-// Start starts streaming JSON file line by line. If an error occurs, the channel
-// will be closed.
-// To handle large JSON files and limited resources efficiently, we can use a streaming-based
-// approach to read the file in chunks rather than reading the entire file at once.
-// It allows us to handle large JSON files without loading the entire file into memory.
-// This way, we can process the JSON data in smaller portions and reduce memory usage.
+// Start starts streaming the file record by record with the decoder of the stream. If an error occurs,
+// the channel will be closed.
+// To handle large files and limited resources efficiently, the decoders read the file in chunks rather than
+// reading the entire file at once. It allows us to handle large files without loading the entire file into memory.
 func (s Stream) Start(file io.Reader) {
+	s.StartContext(context.Background(), file)
+}
+
+// StartContext starts streaming the file like Start does until the context is done. Once it is done, the reading
+// stops at the next record and the channel is closed, the entries which have not been received are dropped,
+// so the client code can stop receiving them.
+func (s Stream) StartContext(ctx context.Context, file io.Reader) {
 	// Stop streaming channel as soon as nothing left to read in the file.
 	defer close(s.stream)
 
+	s.decoder.Decode(ctx, file, func(entry Entry) {
+		s.emit(ctx, entry)
+	})
+}
+
+func (s Stream) emit(ctx context.Context, entry Entry) {
+	if entry.Record > 0 && s.hooks.Record != nil {
+		s.hooks.Record(entry)
+	}
+	select {
+	case s.stream <- entry:
+	case <-ctx.Done():
+	}
+}
+
+// JSONDecoder decodes a file which holds the ports in a single JSON object keyed by their UN/LOCODE.
+// The object is read token by token, so only one port is kept in the memory at a time.
+type JSONDecoder struct{}
+
+func (JSONDecoder) Decode(ctx context.Context, file io.Reader, emit func(Entry)) {
 	decoder := json.NewDecoder(file)
 
 	// Check for the opening curly braces to start the JSON data
 	tok, err := decoder.Token()
 	if err != nil {
-		s.stream <- Entry{Error: fmt.Errorf("decode opening delimiter: %w", err)}
+		emit(Entry{Error: fmt.Errorf("decode opening delimiter: %w", err)})
 		return
 	}
 	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
-		s.stream <- Entry{Error: fmt.Errorf("expected '{' at the beginning of JSON data")}
+		emit(Entry{Error: fmt.Errorf("expected '{' at the beginning of JSON data")})
 		return
 	}
 
 	// Read file content as long as there is something.
 	i := 1
 	for decoder.More() {
+		if ctx.Err() != nil {
+			return
+		}
+
 		portCode, err := decoder.Token()
 		if err != nil {
-			s.stream <- Entry{Error: fmt.Errorf("decode line %d: %w", i, err)}
+			emit(Entry{Error: fmt.Errorf("decode line %d: %w", i, err)})
 			return
 		}
 
-		var port model.Port
-		err = decoder.Decode(&port)
+		// the record is read as a whole first, so a port which can not be decoded does not break the stream
+		var raw json.RawMessage
+		err = decoder.Decode(&raw)
 		if err != nil {
-			s.stream <- Entry{Error: fmt.Errorf("decode line %d: %w", i, err)}
+			emit(Entry{Error: fmt.Errorf("decode line %d: %w", i, err)})
 			return
 		}
 
-		s.stream <- Entry{Port: port, PortCode: portCode.(string)}
+		code, _ := portCode.(string)
+		emit(decodePort(i, code, raw))
 		i++
 	}
 
 	// Check for the closing curly braces to end the JSON data
 	tok, err = decoder.Token()
 	if err != nil {
-		s.stream <- Entry{Error: fmt.Errorf("decode closing delimiter: %w", err)}
+		emit(Entry{Error: fmt.Errorf("decode closing delimiter: %w", err)})
 		return
 	}
 
 	if delim, ok := tok.(json.Delim); !ok || delim != '}' {
-		s.stream <- Entry{Error: fmt.Errorf("expected '}' at the end of JSON data")}
+		emit(Entry{Error: fmt.Errorf("expected '}' at the end of JSON data")})
 		return
 	}
 }
+
+// decodePort decodes a port of a JSON file, a port which can not be decoded is returned with an ErrInvalidRecord.
+func decodePort(record int, code string, raw json.RawMessage) Entry {
+	var port model.Port
+	err := json.Unmarshal(raw, &port)
+	if err == nil {
+		// the legacy documents are converted by the decoder, so they only have to be marked with the current version
+		err = port.Upgrade()
+	}
+	if err != nil {
+		return Entry{PortCode: code, Record: record, Error: ErrInvalidRecord{Record: record, PortCode: code, Err: err}}
+	}
+	return Entry{Port: port, PortCode: code, Record: record}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 
@@ -74,11 +75,12 @@ func TestJSONStream_Start(t *testing.T) {
 
 	expectedPorts := map[string]model.Port{
 		"AEAJM": {
+			Version:     model.PortVersion,
 			Name:        "Ajman",
 			City:        "Ajman",
 			Country:     "United Arab Emirates",
-			Alias:       []interface{}{},
-			Regions:     []interface{}{},
+			Alias:       []string{},
+			Regions:     []model.Region{},
 			Coordinates: []float64{55.5136433, 25.4052165},
 			Province:    "Ajman",
 			Timezone:    "Asia/Dubai",
@@ -86,13 +88,14 @@ func TestJSONStream_Start(t *testing.T) {
 			Code:        "52000",
 		},
 		"AEAUH": {
+			Version:     model.PortVersion,
 			Name:        "Abu Dhabi",
 			Coordinates: []float64{54.37, 24.47},
 			City:        "Abu Dhabi",
 			Province:    "Abu Z¸aby [Abu Dhabi]",
 			Country:     "United Arab Emirates",
-			Alias:       []interface{}{},
-			Regions:     []interface{}{},
+			Alias:       []string{},
+			Regions:     []model.Region{},
 			Timezone:    "Asia/Dubai",
 			Unlocs:      []string{"AEAUH"},
 			Code:        "52001",
@@ -129,6 +132,23 @@ func TestJSONStream_Start(t *testing.T) {
 	// require.NoError(t, tmpfile.Close(), "Failed to close temporary file")
 }
 
+func TestJSONStream_Hooks(t *testing.T) {
+	data := `{"AEAJM": {"name": "Ajman", "unlocs": ["AEAJM"]}, "AEAUH": {"name": 1}, "AEDXB": {"name": "Dubai"}}`
+
+	var records []int
+	jsonStream := NewJSONStream().WithHooks(StreamHooks{Record: func(entry Entry) {
+		records = append(records, entry.Record)
+	}})
+	go jsonStream.Start(strings.NewReader(data))
+
+	var entries []int
+	for entry := range jsonStream.Watch() {
+		entries = append(entries, entry.Record)
+	}
+	require.Equal(t, entries, records)
+	require.Equal(t, []int{1, 2, 3}, records, "the records which can not be decoded are passed to the hook too")
+}
+
 // Helper function to compare two Port structs for equality.
 func portsEqual(p1, p2 model.Port) bool {
 	p1JSON, _ := json.Marshal(p1)
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/internal/port/repository"
+)
+
+// syncPageLimit is the number of the stored ports read at once when a sync looks for the missing ports.
+const syncPageLimit = 500
+
+// keySet is the set of the ports found in the file by a sync, it is safe for concurrent use.
+type keySet struct {
+	mu   sync.Mutex
+	keys map[string]struct{}
+}
+
+// add adds the key to the set, it is a no-op for a nil set.
+func (k *keySet) add(key string) {
+	if k == nil {
+		return
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[key] = struct{}{}
+}
+
+func (k *keySet) contains(key string) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	_, found := k.keys[key]
+	return found
+}
+
+// deleteMissingPorts deletes the stored ports which are not in the file in the transaction of the import.
+func (s PortService) deleteMissingPorts(ctx context.Context, tx repository.Transaction, seen *keySet, report *reportBuilder) error {
+	missing, err := s.missingPorts(ctx, seen)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range missing {
+		err = tx.Delete(ctx, record.Unlocode)
+		switch {
+		case err == nil:
+			report.add(outcomeDeleted)
+		case errors.As(err, &repository.ErrObjectNotFound{}):
+			// it has been deleted in the meantime
+		default:
+			return fmt.Errorf("error deleting port with ID %s: %w", record.Unlocode, err)
+		}
+	}
+	return nil
+}
+
+// missingPorts returns the stored ports which are not in the seen set. The stored ports are read page by page,
+// so only the missing ones are kept in the memory.
+func (s PortService) missingPorts(ctx context.Context, seen *keySet) ([]model.PortRecord, error) {
+	var missing []model.PortRecord
+	page := model.PageRequest{Limit: syncPageLimit}
+	for {
+		result, err := s.repository.ListPage(ctx, page)
+		if err != nil {
+			return nil, fmt.Errorf("list stored ports: %w", err)
+		}
+
+		for _, record := range result.Items {
+			if !seen.contains(record.Unlocode) {
+				missing = append(missing, record)
+			}
+		}
+
+		if result.NextCursor == "" {
+			return missing, nil
+		}
+		page.Cursor = result.NextCursor
+	}
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fir1/port/config"
+	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/internal/port/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const syncTestFile = `{
+  "AEAJM": {"name": "Ajman", "city": "Ajman", "country": "United Arab Emirates", "unlocs": ["AEAJM"]},
+  "AEAUH": {"name": "Abu Dhabi", "city": "Abu Dhabi", "country": "United Arab Emirates", "unlocs": ["AEAUH"], "timezone": "Asia/Dubai"},
+  "AEDXB": {"name": "Dubai", "city": "Dubai", "country": "United Arab Emirates", "unlocs": ["AEDXB"]}
+}`
+
+var syncStoredPorts = map[string]model.Port{
+	"AEAJM": {Version: model.PortVersion, Name: "Ajman", City: "Ajman", Country: "United Arab Emirates", Unlocs: []string{"AEAJM"}},
+	"AEAUH": {Version: model.PortVersion, Name: "Abu Dhabi", City: "Abu Dhabi", Country: "United Arab Emirates", Unlocs: []string{"AEAUH"}},
+	"AEFJR": {Version: model.PortVersion, Name: "Fujairah", City: "Fujairah", Country: "United Arab Emirates", Unlocs: []string{"AEFJR"}},
+}
+
+// failingDeleteRepository fails the deletes of its transactions, so a sync fails after all the ports of the file
+// have been written.
+type failingDeleteRepository struct {
+	repository.PostRepositoryInterface
+}
+
+func (r failingDeleteRepository) Begin(ctx context.Context) (repository.Transaction, error) {
+	tx, err := r.PostRepositoryInterface.Begin(ctx)
+	return failingDeleteTransaction{Transaction: tx}, err
+}
+
+type failingDeleteTransaction struct {
+	repository.Transaction
+}
+
+func (t failingDeleteTransaction) Delete(ctx context.Context, key string) error {
+	return errors.New("delete failed")
+}
+
+func newSyncTestRepository(t *testing.T) repository.PostRepositoryInterface {
+	repo := repository.NewPostRepositoryMemoryDB()
+	for key, port := range syncStoredPorts {
+		require.NoError(t, repo.Create(context.Background(), key, port))
+	}
+	return repo
+}
+
+func writeTestFile(t *testing.T, data string) string {
+	tmpfile, err := os.CreateTemp(t.TempDir(), "ports.json")
+	require.NoError(t, err)
+	_, err = tmpfile.WriteString(data)
+	require.NoError(t, err)
+	require.NoError(t, tmpfile.Close())
+	return tmpfile.Name()
+}
+
+func TestSavePortsFromFile_Sync(t *testing.T) {
+	repo := newSyncTestRepository(t)
+	portService := NewPortService(repo, config.Config{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	report, err := portService.SavePortsFromFile(ctx, writeTestFile(t, syncTestFile), nil, ImportOptions{Sync: true})
+	require.NoError(t, err)
+	assert.True(t, report.Sync)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 1, report.Unchanged)
+	assert.Equal(t, 1, report.Deleted)
+
+	ports, err := repo.ListAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, ports, 3)
+	assert.NotContains(t, ports, "AEFJR")
+	assert.Equal(t, "Asia/Dubai", ports["AEAUH"].Timezone)
+}
+
+func TestSavePortsFromFile_SyncDryRun(t *testing.T) {
+	repo := newSyncTestRepository(t)
+	portService := NewPortService(repo, config.Config{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	report, err := portService.SavePortsFromFile(ctx, writeTestFile(t, syncTestFile), nil, ImportOptions{Sync: true, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Deleted)
+	require.Len(t, report.Diff, 3)
+	assert.Equal(t, "AEAUH", report.Diff[0].Unlocode)
+	assert.Equal(t, "AEDXB", report.Diff[1].Unlocode)
+	assert.Equal(t, PortDiff{
+		Unlocode: "AEFJR",
+		Action:   DiffActionRemoved,
+		Changes: []FieldChange{
+			{Field: "name", Type: FieldRemoved, Old: "Fujairah"},
+			{Field: "city", Type: FieldRemoved, Old: "Fujairah"},
+			{Field: "country", Type: FieldRemoved, Old: "United Arab Emirates"},
+			{Field: "unlocs", Type: FieldRemoved, Old: []string{"AEFJR"}},
+		},
+	}, report.Diff[2])
+
+	ports, err := repo.ListAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, syncStoredPorts, ports, "a dry run must not write anything")
+}
+
+func TestSavePortsFromFile_SyncIsAllOrNothing(t *testing.T) {
+	invalidFile := `{
+  "AEAUH": {"name": "Abu Dhabi", "city": "Abu Dhabi", "country": "United Arab Emirates", "unlocs": ["AEAUH"], "timezone": "Asia/Dubai"},
+  "AEDXB": {"name": "Dubai", "city": "Dubai", "country": "United Arab Emirates", "unlocs": ["AEDXB"]},
+  "AEJEA": {"name": "Jebel Ali", "city": "Jebel Ali", "country": "United Arab Emirates"}
+}`
+
+	testCases := []struct {
+		name  string
+		repo  func(t *testing.T) repository.PostRepositoryInterface
+		file  string
+		check func(t *testing.T, err error)
+	}{
+		{
+			name: "InvalidRecord",
+			repo: newSyncTestRepository,
+			file: invalidFile,
+			check: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "port AEJEA is invalid")
+			},
+		},
+		{
+			name: "FailedWrite",
+			repo: func(t *testing.T) repository.PostRepositoryInterface {
+				return failingDeleteRepository{PostRepositoryInterface: newSyncTestRepository(t)}
+			},
+			file: syncTestFile,
+			check: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "delete failed")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := tc.repo(t)
+			portService := NewPortService(repo, config.Config{})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			report, err := portService.SavePortsFromFile(ctx, writeTestFile(t, tc.file), nil, ImportOptions{Sync: true})
+			tc.check(t, err)
+			assert.True(t, report.RolledBack)
+
+			ports, err := repo.ListAll(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, syncStoredPorts, ports, "a failed sync must leave the stored ports intact")
+		})
+	}
+}
+
+func TestSavePortsFromFile_SyncLenient(t *testing.T) {
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+
+	_, err := portService.SavePortsFromFile(context.Background(), "ports.json", nil, ImportOptions{Sync: true, Mode: ImportModeLenient})
+	assert.ErrorAs(t, err, &ErrInvalidQuery{})
+}
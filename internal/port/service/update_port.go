@@ -0,0 +1,144 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/internal/port/repository"
+	"github.com/fir1/port/internal/port/validation"
+)
+
+// ReplacePort stores the port under the given UN/LOCODE, replacing the whole record if it already exists.
+// It reports whether a new port has been created. A port of a legacy version is stored with the current version.
+func (s PortService) ReplacePort(ctx context.Context, unlocode string, port model.Port) (bool, error) {
+	unlocode = NormalizeUnlocode(unlocode)
+
+	err := port.Upgrade()
+	if err != nil {
+		return false, ErrInvalidPort{Reasons: []string{err.Error()}}
+	}
+
+	err = validation.ValidatePort(unlocode, port)
+	if err != nil {
+		return false, err
+	}
+
+	// the port is checked and written at once, so a concurrent write can not make the reported creation wrong
+	created := false
+	err = s.repository.Modify(ctx, unlocode, func(_ model.Port, found bool) (model.Port, error) {
+		created = !found
+		return port, nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("error saving port with ID %s: %w", unlocode, err)
+	}
+	return created, nil
+}
+
+// PatchPort applies a JSON Merge Patch (RFC 7396) document to an existing port and returns the patched port.
+// Fields set to null in the patch are removed, nested objects are merged and everything else is replaced.
+func (s PortService) PatchPort(ctx context.Context, unlocode string, patch []byte) (model.Port, error) {
+	unlocode = NormalizeUnlocode(unlocode)
+
+	// the patch is applied to the stored port while no other write happens, so two concurrent patches
+	// do not lose the fields of each other
+	var (
+		patched  model.Port
+		patchErr error
+	)
+	err := s.repository.Modify(ctx, unlocode, func(port model.Port, found bool) (model.Port, error) {
+		if !found {
+			patchErr = repository.ErrObjectNotFound{}
+			return model.Port{}, patchErr
+		}
+		patched, patchErr = applyMergePatch(unlocode, port, patch)
+		return patched, patchErr
+	})
+	if patchErr != nil {
+		return model.Port{}, patchErr
+	}
+	if err != nil {
+		return model.Port{}, fmt.Errorf("error updating port with ID %s: %w", unlocode, err)
+	}
+	return patched, nil
+}
+
+// applyMergePatch returns the port with the patch applied, the patched port is checked like a replaced one.
+func applyMergePatch(unlocode string, port model.Port, patch []byte) (model.Port, error) {
+	var patchDoc interface{}
+	err := json.Unmarshal(patch, &patchDoc)
+	if err != nil {
+		return model.Port{}, ErrInvalidPort{Reasons: []string{fmt.Sprintf("malformed merge patch: %v", err)}}
+	}
+
+	portBytes, err := json.Marshal(port)
+	if err != nil {
+		return model.Port{}, err
+	}
+
+	var portDoc interface{}
+	err = json.Unmarshal(portBytes, &portDoc)
+	if err != nil {
+		return model.Port{}, err
+	}
+
+	patchedBytes, err := json.Marshal(mergePatch(portDoc, patchDoc))
+	if err != nil {
+		return model.Port{}, err
+	}
+
+	// unknown fields are most likely typos in the patch, so we don't silently ignore them. The port is decoded
+	// without model.Port.UnmarshalJSON, which can not tell the unknown fields, as a current document: the stored
+	// port has been upgraded, so its aliases are strings.
+	decoder := json.NewDecoder(bytes.NewReader(patchedBytes))
+	decoder.DisallowUnknownFields()
+
+	type currentPort model.Port
+	var decoded currentPort
+	err = decoder.Decode(&decoded)
+	if err != nil {
+		return model.Port{}, ErrInvalidPort{Reasons: []string{fmt.Sprintf("patched port can not be decoded: %v", err)}}
+	}
+	patched := model.Port(decoded)
+
+	err = patched.Upgrade()
+	if err != nil {
+		return model.Port{}, ErrInvalidPort{Reasons: []string{err.Error()}}
+	}
+
+	err = validation.ValidatePort(unlocode, patched)
+	if err != nil {
+		return model.Port{}, err
+	}
+	return patched, nil
+}
+
+// DeletePort removes the port, repository.ErrObjectNotFound is returned when there is no such port.
+func (s PortService) DeletePort(ctx context.Context, unlocode string) error {
+	return s.repository.Delete(ctx, NormalizeUnlocode(unlocode))
+}
+
+// mergePatch implements the MergePatch algorithm from RFC 7396.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
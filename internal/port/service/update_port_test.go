@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/fir1/port/config"
+	"github.com/fir1/port/internal/port/model"
+	"github.com/fir1/port/internal/port/repository"
+	"github.com/fir1/port/internal/port/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	// test cases are taken from the Appendix A of RFC 7396
+	testCases := []struct {
+		target string
+		patch  string
+		result string
+	}{
+		{target: `{"a":"b"}`, patch: `{"a":"c"}`, result: `{"a":"c"}`},
+		{target: `{"a":"b"}`, patch: `{"b":"c"}`, result: `{"a":"b","b":"c"}`},
+		{target: `{"a":"b"}`, patch: `{"a":null}`, result: `{}`},
+		{target: `{"a":"b","b":"c"}`, patch: `{"a":null}`, result: `{"b":"c"}`},
+		{target: `{"a":["b"]}`, patch: `{"a":"c"}`, result: `{"a":"c"}`},
+		{target: `{"a":"c"}`, patch: `{"a":["b"]}`, result: `{"a":["b"]}`},
+		{target: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, result: `{"a":{"b":"d"}}`},
+		{target: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, result: `{"a":[1]}`},
+		{target: `["a","b"]`, patch: `["c","d"]`, result: `["c","d"]`},
+		{target: `{"a":"b"}`, patch: `["c"]`, result: `["c"]`},
+		{target: `{"a":"foo"}`, patch: `null`, result: `null`},
+		{target: `{"e":null}`, patch: `{"a":1}`, result: `{"a":1,"e":null}`},
+		{target: `[1,2]`, patch: `{"a":"b","c":null}`, result: `{"a":"b"}`},
+		{target: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, result: `{"a":{"bb":{}}}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.patch, func(t *testing.T) {
+			var target, patch interface{}
+			require.NoError(t, json.Unmarshal([]byte(tc.target), &target))
+			require.NoError(t, json.Unmarshal([]byte(tc.patch), &patch))
+
+			result, err := json.Marshal(mergePatch(target, patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.result, string(result))
+		})
+	}
+}
+
+func TestPortService_CRUD(t *testing.T) {
+	ctx := context.Background()
+	portService := NewPortService(repository.NewPostRepositoryMemoryDB(), config.Config{})
+
+	port := model.Port{
+		Name:        "Ajman",
+		City:        "Ajman",
+		Country:     "United Arab Emirates",
+		Coordinates: []float64{55.5136433, 25.4052165},
+		Province:    "Ajman",
+		Timezone:    "Asia/Dubai",
+		Unlocs:      []string{"AEAJM"},
+		Code:        "52000",
+	}
+
+	created, err := portService.ReplacePort(ctx, "aeajm", port)
+	require.NoError(t, err)
+	assert.True(t, created, "port must be created")
+
+	created, err = portService.ReplacePort(ctx, "AEAJM", port)
+	require.NoError(t, err)
+	assert.False(t, created, "port must be replaced")
+
+	_, err = portService.ReplacePort(ctx, "AEAJM", model.Port{Coordinates: []float64{1}})
+	assert.ErrorAs(t, err, &validation.ValidationError{})
+
+	patched, err := portService.PatchPort(ctx, "AEAJM", []byte(`{"name":"Ajman Port","province":null}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Ajman Port", patched.Name)
+	assert.Empty(t, patched.Province)
+	assert.Equal(t, port.Coordinates, patched.Coordinates)
+
+	stored, err := portService.GetPort(ctx, "AEAJM")
+	require.NoError(t, err)
+	assert.Equal(t, patched, stored)
+
+	_, err = portService.PatchPort(ctx, "AEAJM", []byte(`{"nmae":"typo"}`))
+	assert.ErrorAs(t, err, &ErrInvalidPort{})
+
+	_, err = portService.PatchPort(ctx, "AEAJM", []byte(`{"name":null}`))
+	assert.ErrorAs(t, err, &validation.ValidationError{})
+
+	_, err = portService.PatchPort(ctx, "AEAUH", []byte(`{"name":"Abu Dhabi"}`))
+	assert.ErrorAs(t, err, &repository.ErrObjectNotFound{})
+
+	require.NoError(t, portService.DeletePort(ctx, "AEAJM"))
+	assert.ErrorAs(t, portService.DeletePort(ctx, "AEAJM"), &repository.ErrObjectNotFound{})
+
+	_, err = portService.GetPort(ctx, "AEAJM")
+	assert.ErrorAs(t, err, &repository.ErrObjectNotFound{})
+}
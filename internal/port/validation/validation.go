@@ -0,0 +1,108 @@
+// Package validation checks the ports before they are stored.
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	// the time zones are validated against the embedded IANA database, so the result does not depend on the host
+	_ "time/tzdata"
+
+	"github.com/fir1/port/internal/port/model"
+)
+
+// unlocodeFormat is the UN/LOCODE format: ISO 3166-1 alpha-2 country code followed by three letters or digits 2-9.
+var unlocodeFormat = regexp.MustCompile(`^[A-Z]{2}[A-Z2-9]{3}$`)
+
+// Violation is a single rule broken by a port, the field is the path of the field in the port document.
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return v.Field + ": " + v.Message
+}
+
+// ValidationError lists every violation of the port.
+type ValidationError struct {
+	Unlocode   string
+	Violations []Violation
+}
+
+func (e ValidationError) Error() string {
+	violations := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		violations = append(violations, v.String())
+	}
+	return fmt.Sprintf("port %s is invalid: %s", e.Unlocode, strings.Join(violations, "; "))
+}
+
+// ValidatePort checks the port stored under the UN/LOCODE, it returns ValidationError with all the violations
+// or nil when the port is valid. Coordinates and timezone are optional, but they must be valid when present.
+func ValidatePort(unlocode string, port model.Port) error {
+	var violations []Violation
+	add := func(field, format string, args ...interface{}) {
+		violations = append(violations, Violation{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case unlocode == "":
+		add("unlocode", "is required")
+	case !unlocodeFormat.MatchString(unlocode):
+		add("unlocode", "%q is not a valid UN/LOCODE", unlocode)
+	}
+
+	for _, required := range []struct {
+		field string
+		value string
+	}{
+		{field: "name", value: port.Name},
+		{field: "city", value: port.City},
+		{field: "country", value: port.Country},
+	} {
+		if strings.TrimSpace(required.value) == "" {
+			add(required.field, "is required")
+		}
+	}
+
+	if len(port.Unlocs) == 0 {
+		add("unlocs", "is required")
+	}
+	containsUnlocode := false
+	for i, unloc := range port.Unlocs {
+		if !unlocodeFormat.MatchString(unloc) {
+			add(fmt.Sprintf("unlocs[%d]", i), "%q is not a valid UN/LOCODE", unloc)
+		}
+		containsUnlocode = containsUnlocode || unloc == unlocode
+	}
+	if len(port.Unlocs) > 0 && unlocode != "" && !containsUnlocode {
+		add("unlocs", "must contain the port UN/LOCODE %s", unlocode)
+	}
+
+	switch len(port.Coordinates) {
+	case 0:
+	case 2:
+		if lon := port.Coordinates[0]; lon < -180 || lon > 180 {
+			add("coordinates[0]", "longitude %v must be between -180 and 180", lon)
+		}
+		if lat := port.Coordinates[1]; lat < -90 || lat > 90 {
+			add("coordinates[1]", "latitude %v must be between -90 and 90", lat)
+		}
+	default:
+		add("coordinates", "must contain exactly two values [lon, lat], got %d", len(port.Coordinates))
+	}
+
+	if port.Timezone != "" {
+		if _, err := time.LoadLocation(port.Timezone); err != nil || port.Timezone == "Local" {
+			add("timezone", "%q is not a valid IANA time zone", port.Timezone)
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return ValidationError{Unlocode: unlocode, Violations: violations}
+}
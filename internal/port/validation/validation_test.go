@@ -0,0 +1,134 @@
+package validation
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/fir1/port/internal/port/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePort(t *testing.T) {
+	valid := model.Port{
+		Name:        "Ajman",
+		City:        "Ajman",
+		Country:     "United Arab Emirates",
+		Coordinates: []float64{55.5136433, 25.4052165},
+		Timezone:    "Asia/Dubai",
+		Unlocs:      []string{"AEAJM"},
+	}
+
+	testCases := []struct {
+		name               string
+		unlocode           string
+		port               func(port model.Port) model.Port
+		expectedViolations []Violation
+	}{
+		{
+			name:     "Valid",
+			unlocode: "AEAJM",
+			port:     func(port model.Port) model.Port { return port },
+		},
+		{
+			name:     "ValidWithoutOptionalFields",
+			unlocode: "AEAJM",
+			port: func(port model.Port) model.Port {
+				port.Coordinates, port.Timezone = nil, ""
+				return port
+			},
+		},
+		{
+			name:     "ValidDigitsInUnlocode",
+			unlocode: "US2AB",
+			port: func(port model.Port) model.Port {
+				port.Unlocs = []string{"US2AB", "USXYZ"}
+				return port
+			},
+		},
+		{
+			name:     "InvalidUnlocodes",
+			unlocode: "aeajm",
+			port: func(port model.Port) model.Port {
+				port.Unlocs = []string{"AEAJM", "AE10X"}
+				return port
+			},
+			expectedViolations: []Violation{
+				{Field: "unlocode", Message: `"aeajm" is not a valid UN/LOCODE`},
+				{Field: "unlocs[1]", Message: `"AE10X" is not a valid UN/LOCODE`},
+				{Field: "unlocs", Message: "must contain the port UN/LOCODE aeajm"},
+			},
+		},
+		{
+			name:     "MissingRequiredFields",
+			unlocode: "",
+			port: func(port model.Port) model.Port {
+				port.Name, port.City, port.Country, port.Unlocs = "", " ", "", nil
+				return port
+			},
+			expectedViolations: []Violation{
+				{Field: "unlocode", Message: "is required"},
+				{Field: "name", Message: "is required"},
+				{Field: "city", Message: "is required"},
+				{Field: "country", Message: "is required"},
+				{Field: "unlocs", Message: "is required"},
+			},
+		},
+		{
+			name:     "InvalidCoordinatesAndTimezone",
+			unlocode: "AEAJM",
+			port: func(port model.Port) model.Port {
+				port.Coordinates = []float64{-181, 90.5}
+				port.Timezone = "America/Argentina"
+				return port
+			},
+			expectedViolations: []Violation{
+				{Field: "coordinates[0]", Message: "longitude -181 must be between -180 and 180"},
+				{Field: "coordinates[1]", Message: "latitude 90.5 must be between -90 and 90"},
+				{Field: "timezone", Message: `"America/Argentina" is not a valid IANA time zone`},
+			},
+		},
+		{
+			name:     "WrongNumberOfCoordinates",
+			unlocode: "AEAJM",
+			port: func(port model.Port) model.Port {
+				port.Coordinates = []float64{55.5}
+				port.Timezone = "Local"
+				return port
+			},
+			expectedViolations: []Violation{
+				{Field: "coordinates", Message: "must contain exactly two values [lon, lat], got 1"},
+				{Field: "timezone", Message: `"Local" is not a valid IANA time zone`},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidatePort(tc.unlocode, tc.port(valid))
+			if tc.expectedViolations == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationErr ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tc.unlocode, validationErr.Unlocode)
+			assert.Equal(t, tc.expectedViolations, validationErr.Violations)
+		})
+	}
+}
+
+func TestValidatePort_DataFile(t *testing.T) {
+	data, err := os.ReadFile("../../../data/ports.json")
+	require.NoError(t, err)
+
+	var ports map[string]model.Port
+	require.NoError(t, json.Unmarshal(data, &ports))
+
+	// the bundled ports file is imported by POST /ports, so every port in it must be valid
+	for unlocode, port := range ports {
+		assert.NoError(t, ValidatePort(unlocode, port))
+	}
+}
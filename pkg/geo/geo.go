@@ -0,0 +1,118 @@
+// Package geo contains the great-circle calculations on the Earth surface and a spatial index of points.
+package geo
+
+import "math"
+
+const (
+	// EarthRadiusKm is the mean radius of the Earth.
+	EarthRadiusKm = 6371.0088
+	// MaxDistanceKm is the longest great-circle distance between two points, half of the Earth circumference.
+	MaxDistanceKm = math.Pi * EarthRadiusKm
+)
+
+// Point is a location on the Earth surface in degrees.
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// Valid reports whether the latitude and longitude are within their bounds.
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lon >= -180 && p.Lon <= 180
+}
+
+// Distance returns the great-circle distance between the points in kilometres using the haversine formula.
+func Distance(from, to Point) float64 {
+	lat1, lat2 := radians(from.Lat), radians(to.Lat)
+	dLat := lat2 - lat1
+	dLon := radians(to.Lon - from.Lon)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// BoundingBox is the smallest latitude/longitude box which contains a circle on the Earth surface.
+// When the box crosses the antimeridian MinLon is greater than MaxLon.
+type BoundingBox struct {
+	MinLat, MaxLat float64
+	MinLon, MaxLon float64
+}
+
+// AllLongitudes reports whether the box covers every longitude, it happens when the circle contains a pole.
+func (b BoundingBox) AllLongitudes() bool {
+	return b.MinLon == -180 && b.MaxLon == 180
+}
+
+// CrossesAntimeridian reports whether the box wraps around the 180th meridian.
+func (b BoundingBox) CrossesAntimeridian() bool {
+	return b.MinLon > b.MaxLon
+}
+
+// BoundingBoxAround returns the bounding box of the circle with the radius in kilometres around the center.
+// See http://janmatuschek.de/LatitudeLongitudeBoundingCoordinates for the details.
+func BoundingBoxAround(center Point, radiusKm float64) BoundingBox {
+	angular := radiusKm / EarthRadiusKm
+	lat := radians(center.Lat)
+
+	minLat, maxLat := lat-angular, lat+angular
+	if minLat <= -math.Pi/2 || maxLat >= math.Pi/2 {
+		return BoundingBox{
+			MinLat: degrees(math.Max(minLat, -math.Pi/2)),
+			MaxLat: degrees(math.Min(maxLat, math.Pi/2)),
+			MinLon: -180,
+			MaxLon: 180,
+		}
+	}
+
+	deltaLon := degrees(math.Asin(math.Sin(angular) / math.Cos(lat)))
+	minLon, maxLon := center.Lon-deltaLon, center.Lon+deltaLon
+	if maxLon-minLon >= 360 {
+		minLon, maxLon = -180, 180
+	}
+	return BoundingBox{
+		MinLat: degrees(minLat),
+		MaxLat: degrees(maxLat),
+		MinLon: normalizeLon(minLon),
+		MaxLon: normalizeLon(maxLon),
+	}
+}
+
+func normalizeLon(lon float64) float64 {
+	switch {
+	case lon == -180 || lon == 180:
+		return lon
+	case lon < -180:
+		return lon + 360
+	case lon > 180:
+		return lon - 360
+	default:
+		return lon
+	}
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// KmPerNauticalMile is the length of the international nautical mile in kilometres.
+const KmPerNauticalMile = 1.852
+
+// KmToNauticalMiles converts the distance in kilometres to nautical miles.
+func KmToNauticalMiles(km float64) float64 {
+	return km / KmPerNauticalMile
+}
+
+// InitialBearing returns the initial bearing (forward azimuth) of the great-circle path from one point to another
+// in degrees clockwise from the true north, in the range [0, 360).
+func InitialBearing(from, to Point) float64 {
+	lat1, lat2 := radians(from.Lat), radians(to.Lat)
+	dLon := radians(to.Lon - from.Lon)
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
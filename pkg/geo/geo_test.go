@@ -0,0 +1,104 @@
+package geo
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistance(t *testing.T) {
+	testCases := []struct {
+		name     string
+		from, to Point
+		expected float64
+	}{
+		{name: "SamePoint", from: Point{Lat: 25.4, Lon: 55.5}, to: Point{Lat: 25.4, Lon: 55.5}, expected: 0},
+		{name: "Equator1Degree", from: Point{Lat: 0, Lon: 0}, to: Point{Lat: 0, Lon: 1}, expected: 111.195},
+		{name: "PoleToPole", from: Point{Lat: 90, Lon: 0}, to: Point{Lat: -90, Lon: 0}, expected: MaxDistanceKm},
+		{name: "AcrossAntimeridian", from: Point{Lat: 0, Lon: 179.5}, to: Point{Lat: 0, Lon: -179.5}, expected: 111.195},
+		{name: "LondonToParis", from: Point{Lat: 51.5074, Lon: -0.1278}, to: Point{Lat: 48.8566, Lon: 2.3522}, expected: 343.56},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.InDelta(t, tc.expected, Distance(tc.from, tc.to), 0.01)
+			assert.InDelta(t, tc.expected, Distance(tc.to, tc.from), 0.01)
+		})
+	}
+}
+
+func TestBoundingBoxAround(t *testing.T) {
+	box := BoundingBoxAround(Point{Lat: 0, Lon: 179.5}, 200)
+	assert.True(t, box.CrossesAntimeridian())
+	assert.False(t, box.AllLongitudes())
+
+	box = BoundingBoxAround(Point{Lat: 89, Lon: 10}, 200)
+	assert.True(t, box.AllLongitudes())
+	assert.Equal(t, 90.0, box.MaxLat)
+
+	box = BoundingBoxAround(Point{Lat: 25, Lon: 55}, 100)
+	assert.False(t, box.CrossesAntimeridian())
+	assert.Less(t, box.MinLon, 55.0)
+	assert.Greater(t, box.MaxLon, 55.0)
+}
+
+func TestIndex_Nearby(t *testing.T) {
+	random := rand.New(rand.NewSource(1)) //nolint:gosec // deterministic points for the test
+	index := NewIndex(DefaultCellSizeDeg)
+
+	points := map[string]Point{}
+	for i := 0; i < 5000; i++ {
+		id := strconv.Itoa(i)
+		points[id] = Point{Lat: random.Float64()*180 - 90, Lon: random.Float64()*360 - 180}
+		index.Insert(id, points[id])
+	}
+
+	// moved and removed points must not be found at their old location
+	index.Insert("0", Point{Lat: 10, Lon: 10})
+	points["0"] = Point{Lat: 10, Lon: 10}
+	index.Remove("1")
+	delete(points, "1")
+	require.Equal(t, len(points), index.Len())
+
+	centers := []Point{{Lat: 10, Lon: 10}, {Lat: 0, Lon: 179.9}, {Lat: 89.5, Lon: 0}, {Lat: -45, Lon: -180}}
+	for _, center := range centers {
+		for _, radius := range []float64{50, 500, 5000, MaxDistanceKm} {
+			var expected []Neighbour
+			for id, p := range points {
+				if distance := Distance(center, p); distance <= radius {
+					expected = append(expected, Neighbour{ID: id, Point: p, DistanceKm: distance})
+				}
+			}
+			SortNeighbours(expected)
+
+			assert.Equal(t, expected, index.Nearby(center, radius, 0), "center %v radius %v", center, radius)
+			if len(expected) > 3 {
+				assert.Equal(t, expected[:3], index.Nearby(center, radius, 3))
+			}
+		}
+	}
+}
+
+func TestInitialBearing(t *testing.T) {
+	testCases := []struct {
+		name     string
+		from, to Point
+		expected float64
+	}{
+		{name: "North", from: Point{Lat: 0, Lon: 0}, to: Point{Lat: 10, Lon: 0}, expected: 0},
+		{name: "East", from: Point{Lat: 0, Lon: 0}, to: Point{Lat: 0, Lon: 10}, expected: 90},
+		{name: "South", from: Point{Lat: 10, Lon: 0}, to: Point{Lat: 0, Lon: 0}, expected: 180},
+		{name: "West", from: Point{Lat: 0, Lon: 10}, to: Point{Lat: 0, Lon: 0}, expected: 270},
+		{name: "AcrossAntimeridian", from: Point{Lat: 0, Lon: 179.5}, to: Point{Lat: 0, Lon: -179.5}, expected: 90},
+		{name: "LondonToParis", from: Point{Lat: 51.5074, Lon: -0.1278}, to: Point{Lat: 48.8566, Lon: 2.3522}, expected: 148.12},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.InDelta(t, tc.expected, InitialBearing(tc.from, tc.to), 0.01)
+		})
+	}
+}
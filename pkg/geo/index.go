@@ -0,0 +1,138 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultCellSizeDeg is the cell size which gives a few cells for the typical search radius of tens of kilometres.
+const DefaultCellSizeDeg = 1.0
+
+// Neighbour is a point found around the search center.
+type Neighbour struct {
+	ID         string
+	Point      Point
+	DistanceKm float64
+}
+
+type cell struct {
+	row, col int
+}
+
+// Index is a spatial index which buckets the points into a grid of equal latitude/longitude cells, similar to
+// geohash prefixes. A search only visits the cells which intersect the bounding box of the search circle,
+// so it does not check every point. Index is not safe for concurrent use.
+type Index struct {
+	cellSize float64
+	cols     int
+	cells    map[cell]map[string]Point
+	points   map[string]Point
+}
+
+// NewIndex returns an empty index with the cell size in degrees.
+func NewIndex(cellSizeDeg float64) *Index {
+	return &Index{
+		cellSize: cellSizeDeg,
+		cols:     int(math.Ceil(360 / cellSizeDeg)),
+		cells:    map[cell]map[string]Point{},
+		points:   map[string]Point{},
+	}
+}
+
+// Len returns the number of the indexed points.
+func (i *Index) Len() int {
+	return len(i.points)
+}
+
+// Insert adds the point with the id, the previous point with the same id is replaced.
+func (i *Index) Insert(id string, p Point) {
+	i.Remove(id)
+
+	c := i.cellOf(p)
+	points, found := i.cells[c]
+	if !found {
+		points = map[string]Point{}
+		i.cells[c] = points
+	}
+	points[id] = p
+	i.points[id] = p
+}
+
+// Remove removes the point with the id, it is a no-op when there is no such point.
+func (i *Index) Remove(id string) {
+	p, found := i.points[id]
+	if !found {
+		return
+	}
+
+	c := i.cellOf(p)
+	delete(i.cells[c], id)
+	if len(i.cells[c]) == 0 {
+		delete(i.cells, c)
+	}
+	delete(i.points, id)
+}
+
+// Nearby returns up to limit points within the radius in kilometres around the center, the closest point first.
+// Points with the same distance are ordered by their id.
+func (i *Index) Nearby(center Point, radiusKm float64, limit int) []Neighbour {
+	box := BoundingBoxAround(center, radiusKm)
+
+	minRow, maxRow := i.row(box.MinLat), i.row(box.MaxLat)
+	var colRanges [][2]int
+	switch {
+	case box.AllLongitudes():
+		colRanges = [][2]int{{0, i.cols - 1}}
+	case box.CrossesAntimeridian():
+		colRanges = [][2]int{{i.col(box.MinLon), i.cols - 1}, {0, i.col(box.MaxLon)}}
+	default:
+		colRanges = [][2]int{{i.col(box.MinLon), i.col(box.MaxLon)}}
+	}
+
+	var neighbours []Neighbour
+	for row := minRow; row <= maxRow; row++ {
+		for _, cols := range colRanges {
+			for col := cols[0]; col <= cols[1]; col++ {
+				for id, p := range i.cells[cell{row: row, col: col}] {
+					distance := Distance(center, p)
+					if distance <= radiusKm {
+						neighbours = append(neighbours, Neighbour{ID: id, Point: p, DistanceKm: distance})
+					}
+				}
+			}
+		}
+	}
+
+	SortNeighbours(neighbours)
+	if limit > 0 && len(neighbours) > limit {
+		neighbours = neighbours[:limit]
+	}
+	return neighbours
+}
+
+// SortNeighbours sorts the neighbours by their distance and then by their id.
+func SortNeighbours(neighbours []Neighbour) {
+	sort.Slice(neighbours, func(a, b int) bool {
+		if neighbours[a].DistanceKm != neighbours[b].DistanceKm {
+			return neighbours[a].DistanceKm < neighbours[b].DistanceKm
+		}
+		return neighbours[a].ID < neighbours[b].ID
+	})
+}
+
+func (i *Index) cellOf(p Point) cell {
+	return cell{row: i.row(p.Lat), col: i.col(p.Lon)}
+}
+
+func (i *Index) row(lat float64) int {
+	return int(math.Floor((lat + 90) / i.cellSize))
+}
+
+func (i *Index) col(lon float64) int {
+	col := int(math.Floor((lon + 180) / i.cellSize))
+	// 180 and -180 are the same meridian
+	if col >= i.cols {
+		col -= i.cols
+	}
+	return col
+}
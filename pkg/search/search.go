@@ -0,0 +1,312 @@
+// Package search contains an in-process full-text index with diacritic folding and typo tolerance.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	exactScore  = 1.0
+	prefixScore = 0.8
+	// minPrefixLen is the shortest query token which matches the longer terms starting with it.
+	minPrefixLen = 3
+)
+
+// Field is a piece of text of a document, the matches in the field are scored with its weight.
+type Field struct {
+	Text   string
+	Weight float64
+}
+
+// Match is a document found by a query.
+type Match struct {
+	ID    string
+	Score float64
+}
+
+// Index is an inverted index which maps the folded tokens of the document fields to the documents.
+// A query token matches the equal terms, the terms starting with it and the terms within a small edit distance,
+// so the typos in the query are tolerated. Index is not safe for concurrent use.
+type Index struct {
+	// postings maps a term to the documents which contain it and the highest weight of the fields with the term
+	postings map[string]map[string]float64
+	// terms are the terms of every document, so the document can be removed from the postings
+	terms map[string][]string
+}
+
+// NewIndex returns an empty index.
+func NewIndex() *Index {
+	return &Index{
+		postings: map[string]map[string]float64{},
+		terms:    map[string][]string{},
+	}
+}
+
+// Len returns the number of the indexed documents.
+func (i *Index) Len() int {
+	return len(i.terms)
+}
+
+// Insert adds the document with the fields, the previous document with the same id is replaced.
+func (i *Index) Insert(id string, fields ...Field) {
+	i.Remove(id)
+
+	weights := map[string]float64{}
+	for _, field := range fields {
+		for _, token := range Tokenize(field.Text) {
+			if field.Weight > weights[token] {
+				weights[token] = field.Weight
+			}
+		}
+	}
+	if len(weights) == 0 {
+		return
+	}
+
+	terms := make([]string, 0, len(weights))
+	for term, weight := range weights {
+		documents, found := i.postings[term]
+		if !found {
+			documents = map[string]float64{}
+			i.postings[term] = documents
+		}
+		documents[id] = weight
+		terms = append(terms, term)
+	}
+	i.terms[id] = terms
+}
+
+// Remove removes the document with the id, it is a no-op when there is no such document.
+func (i *Index) Remove(id string) {
+	for _, term := range i.terms[id] {
+		delete(i.postings[term], id)
+		if len(i.postings[term]) == 0 {
+			delete(i.postings, term)
+		}
+	}
+	delete(i.terms, id)
+}
+
+// Search returns up to limit documents which match the query, the best match first. Each query token contributes
+// the best score of the document terms it matches multiplied by the field weight, the score of the document is
+// the average over the query tokens. Documents with the same score are ordered by their id.
+func (i *Index) Search(query string, limit int) []Match {
+	tokens := unique(Tokenize(query))
+	if len(tokens) == 0 {
+		return []Match{}
+	}
+
+	scores := map[string]float64{}
+	for _, token := range tokens {
+		best := map[string]float64{}
+		for term, documents := range i.postings {
+			score := termScore(token, term)
+			if score == 0 {
+				continue
+			}
+
+			for id, weight := range documents {
+				if score*weight > best[id] {
+					best[id] = score * weight
+				}
+			}
+		}
+
+		for id, score := range best {
+			scores[id] += score / float64(len(tokens))
+		}
+	}
+
+	matches := make([]Match, 0, len(scores))
+	for id, score := range scores {
+		matches = append(matches, Match{ID: id, Score: score})
+	}
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].Score != matches[b].Score {
+			return matches[a].Score > matches[b].Score
+		}
+		return matches[a].ID < matches[b].ID
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// termScore returns how well the query token matches the indexed term, zero means no match.
+func termScore(token, term string) float64 {
+	if token == term {
+		return exactScore
+	}
+	if len(token) >= minPrefixLen && strings.HasPrefix(term, token) {
+		return prefixScore
+	}
+
+	maxEdits := MaxEdits(token)
+	if maxEdits == 0 {
+		return 0
+	}
+	edits := EditDistance(token, term, maxEdits)
+	if edits > maxEdits {
+		return 0
+	}
+	// a single typo scores 0.6, two typos score 0.4
+	return prefixScore - 0.2*float64(edits)
+}
+
+// MaxEdits returns the number of typos tolerated in the token, short tokens must match exactly.
+func MaxEdits(token string) int {
+	switch n := len([]rune(token)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// EditDistance returns the optimal string alignment distance between a and b, which counts insertions,
+// deletions, substitutions and transpositions of adjacent runes. The calculation stops as soon as the distance
+// exceeds the limit, limit+1 is returned then.
+func EditDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if abs(len(ra)-len(rb)) > limit {
+		return limit + 1
+	}
+
+	// three rows of the dynamic programming matrix are enough for the transpositions
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = minInt(curr[j], prev2[j-2]+1)
+			}
+			if curr[j] < rowMin {
+				rowMin = curr[j]
+			}
+		}
+
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	if prev[len(rb)] > limit {
+		return limit + 1
+	}
+	return prev[len(rb)]
+}
+
+// Tokenize folds the text and splits it into words of letters and digits.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(Fold(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Fold lower-cases the text and replaces the Latin letters with diacritics by their base letters, e.g. "Åbo" becomes
+// "abo". Combining marks, spacing modifiers such as the cedilla in "Abu Z¸aby" and apostrophes are dropped,
+// so they do not split the words.
+func Fold(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Mn, r), unicode.Is(unicode.Sk, r), unicode.Is(unicode.Lm, r):
+			continue
+		case r == '\'' || r == '’' || r == '`':
+			continue
+		}
+
+		r = unicode.ToLower(r)
+		if folded, found := foldTable[r]; found {
+			b.WriteString(folded)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var foldTable = func() map[rune]string {
+	table := map[rune]string{}
+	for base, letters := range map[string]string{
+		"a":  "àáâãäåāăą",
+		"c":  "çćĉċč",
+		"d":  "ďđð",
+		"e":  "èéêëēĕėęě",
+		"g":  "ĝğġģ",
+		"h":  "ĥħ",
+		"i":  "ìíîïĩīĭįı",
+		"j":  "ĵ",
+		"k":  "ķ",
+		"l":  "ĺļľŀł",
+		"n":  "ñńņňŉ",
+		"o":  "òóôõöøōŏő",
+		"r":  "ŕŗř",
+		"s":  "śŝşšș",
+		"t":  "ţťŧț",
+		"u":  "ùúûüũūŭůűų",
+		"w":  "ŵ",
+		"y":  "ýÿŷ",
+		"z":  "źżž",
+		"ae": "æ",
+		"oe": "œ",
+		"ss": "ß",
+		"th": "þ",
+	} {
+		for _, letter := range letters {
+			table[letter] = base
+		}
+	}
+	return table
+}()
+
+func unique(tokens []string) []string {
+	seen := make(map[string]struct{}, len(tokens))
+	result := tokens[:0]
+	for _, token := range tokens {
+		if _, found := seen[token]; found {
+			continue
+		}
+		seen[token] = struct{}{}
+		result = append(result, token)
+	}
+	return result
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, v := range values[1:] {
+		if v < result {
+			result = v
+		}
+	}
+	return result
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,99 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected []string
+	}{
+		{text: "Abu Dhabi", expected: []string{"abu", "dhabi"}},
+		{text: "Abu Z¸aby", expected: []string{"abu", "zaby"}},
+		{text: "Åbo/Turku", expected: []string{"abo", "turku"}},
+		{text: "São Tomé", expected: []string{"sao", "tome"}},
+		{text: "Xi'an, Düsseldorf-Hafen", expected: []string{"xian", "dusseldorf", "hafen"}},
+		{text: "Straße 42", expected: []string{"strasse", "42"}},
+		{text: " - ", expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.text, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Tokenize(tc.text))
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		limit    int
+		expected int
+	}{
+		{a: "dubai", b: "dubai", limit: 2, expected: 0},
+		{a: "dabi", b: "dhabi", limit: 1, expected: 1},
+		{a: "dubia", b: "dubai", limit: 1, expected: 1},
+		{a: "kitten", b: "sitting", limit: 3, expected: 3},
+		{a: "kitten", b: "sitting", limit: 2, expected: 3},
+		{a: "ab", b: "abcdef", limit: 2, expected: 3},
+		{a: "málaga", b: "malaga", limit: 1, expected: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.a+"-"+tc.b, func(t *testing.T) {
+			assert.Equal(t, tc.expected, EditDistance(tc.a, tc.b, tc.limit))
+			assert.Equal(t, tc.expected, EditDistance(tc.b, tc.a, tc.limit))
+		})
+	}
+}
+
+func TestIndex_Search(t *testing.T) {
+	index := NewIndex()
+	index.Insert("AEAUH", Field{Text: "Abu Dhabi", Weight: 1}, Field{Text: "Abu Z¸aby", Weight: 0.9})
+	index.Insert("AEDXB", Field{Text: "Dubai", Weight: 1})
+	index.Insert("CNFOC", Field{Text: "Fuzhou", Weight: 1}, Field{Text: "Foochow", Weight: 0.9})
+	index.Insert("JPABU", Field{Text: "Aburatsu", Weight: 1})
+	index.Insert("ESAGP", Field{Text: "Málaga", Weight: 1})
+	require.Equal(t, 5, index.Len())
+
+	testCases := []struct {
+		query    string
+		expected []string
+	}{
+		{query: "Abu Dhabi", expected: []string{"AEAUH", "JPABU"}},
+		{query: "abu dabi", expected: []string{"AEAUH", "JPABU"}},
+		{query: "abu zaby", expected: []string{"AEAUH", "JPABU"}},
+		{query: "Foochow", expected: []string{"CNFOC"}},
+		{query: "dubia", expected: []string{"AEDXB"}},
+		{query: "MALAGA", expected: []string{"ESAGP"}},
+		{query: "abur", expected: []string{"JPABU", "AEAUH"}},
+		{query: "xyz", expected: []string{}},
+		{query: "", expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			ids := []string{}
+			for _, match := range index.Search(tc.query, 10) {
+				ids = append(ids, match.ID)
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+
+	// the exact match must rank above the typo
+	matches := index.Search("abu dabi", 10)
+	assert.Greater(t, matches[0].Score, matches[1].Score)
+
+	// removed and replaced documents must not be found by their old terms
+	index.Remove("AEDXB")
+	index.Insert("CNFOC", Field{Text: "Fuzhou", Weight: 1})
+	assert.Empty(t, index.Search("dubai", 10))
+	assert.Empty(t, index.Search("foochow", 10))
+	assert.Equal(t, 4, index.Len())
+	assert.Len(t, index.Search("abu", 1), 1)
+}
@@ -0,0 +1,192 @@
+package search
+
+import (
+	"sort"
+	"strings"
+)
+
+// Term is a piece of text of a document which can be completed, the completions of the terms with the lower rank
+// are returned first.
+type Term struct {
+	Text string
+	Rank int
+}
+
+// Completion is a document whose term starts with the prefix.
+type Completion struct {
+	ID string
+	// Text is the original text of the term.
+	Text string
+	Rank int
+	// Exact is true when the whole term equals the prefix.
+	Exact bool
+}
+
+type trieEntry struct {
+	id   string
+	term Term
+	// whole is true when the key of the entry is the whole term rather than one of its words to the end
+	whole bool
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	entries  map[trieEntry]struct{}
+}
+
+// Trie is a prefix tree over the folded terms of the documents. Every term is inserted from the start of each
+// of its words, so "abu dh" and "dhabi" both complete "Abu Dhabi". Trie is not safe for concurrent use.
+type Trie struct {
+	root *trieNode
+	// keys are the keys inserted for every document, so the document can be removed
+	keys map[string][]trieKey
+}
+
+type trieKey struct {
+	key   string
+	entry trieEntry
+}
+
+// NewTrie returns an empty trie.
+func NewTrie() *Trie {
+	return &Trie{root: newTrieNode(), keys: map[string][]trieKey{}}
+}
+
+func newTrieNode() *trieNode {
+	return &trieNode{children: map[rune]*trieNode{}, entries: map[trieEntry]struct{}{}}
+}
+
+// Len returns the number of the documents in the trie.
+func (t *Trie) Len() int {
+	return len(t.keys)
+}
+
+// Insert adds the document with the terms, the previous document with the same id is replaced.
+func (t *Trie) Insert(id string, terms ...Term) {
+	t.Remove(id)
+
+	var keys []trieKey
+	for _, term := range terms {
+		words := Tokenize(term.Text)
+		for i := range words {
+			key := trieKey{
+				key:   strings.Join(words[i:], " "),
+				entry: trieEntry{id: id, term: term, whole: i == 0},
+			}
+			t.insert(key)
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) > 0 {
+		t.keys[id] = keys
+	}
+}
+
+// Remove removes the document with the id, it is a no-op when there is no such document.
+func (t *Trie) Remove(id string) {
+	for _, key := range t.keys[id] {
+		t.remove(key)
+	}
+	delete(t.keys, id)
+}
+
+// Complete returns up to limit documents with a term starting with the prefix, one completion per document.
+// Exact matches come first, then the lower ranks, then the shorter terms. Completions which are equal
+// by these criteria are ordered by the text and the id.
+func (t *Trie) Complete(prefix string, limit int) []Completion {
+	key := strings.Join(Tokenize(prefix), " ")
+	if key == "" {
+		return []Completion{}
+	}
+
+	node := t.root
+	for _, r := range key {
+		node = node.children[r]
+		if node == nil {
+			return []Completion{}
+		}
+	}
+
+	best := map[string]Completion{}
+	var walk func(n *trieNode, depth int)
+	walk = func(n *trieNode, depth int) {
+		for entry := range n.entries {
+			completion := Completion{
+				ID:    entry.id,
+				Text:  entry.term.Text,
+				Rank:  entry.term.Rank,
+				Exact: entry.whole && depth == 0,
+			}
+			if current, found := best[entry.id]; !found || completionLess(completion, current) {
+				best[entry.id] = completion
+			}
+		}
+		for _, child := range n.children {
+			walk(child, depth+1)
+		}
+	}
+	walk(node, 0)
+
+	completions := make([]Completion, 0, len(best))
+	for _, completion := range best {
+		completions = append(completions, completion)
+	}
+	sort.Slice(completions, func(a, b int) bool {
+		return completionLess(completions[a], completions[b])
+	})
+
+	if limit > 0 && len(completions) > limit {
+		completions = completions[:limit]
+	}
+	return completions
+}
+
+func completionLess(a, b Completion) bool {
+	switch {
+	case a.Exact != b.Exact:
+		return a.Exact
+	case a.Rank != b.Rank:
+		return a.Rank < b.Rank
+	case len(a.Text) != len(b.Text):
+		return len(a.Text) < len(b.Text)
+	case a.Text != b.Text:
+		return a.Text < b.Text
+	default:
+		return a.ID < b.ID
+	}
+}
+
+func (t *Trie) insert(key trieKey) {
+	node := t.root
+	for _, r := range key.key {
+		child, found := node.children[r]
+		if !found {
+			child = newTrieNode()
+			node.children[r] = child
+		}
+		node = child
+	}
+	node.entries[key.entry] = struct{}{}
+}
+
+// remove removes the entry of the key and prunes the nodes which are left empty.
+func (t *Trie) remove(key trieKey) {
+	path := []*trieNode{t.root}
+	node := t.root
+	for _, r := range key.key {
+		node = node.children[r]
+		if node == nil {
+			return
+		}
+		path = append(path, node)
+	}
+	delete(node.entries, key.entry)
+
+	runes := []rune(key.key)
+	for i := len(path) - 1; i > 0; i-- {
+		if len(path[i].entries) > 0 || len(path[i].children) > 0 {
+			return
+		}
+		delete(path[i-1].children, runes[i-1])
+	}
+}
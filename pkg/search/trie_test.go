@@ -0,0 +1,110 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrie_Complete(t *testing.T) {
+	const (
+		rankCode = iota
+		rankName
+		rankAlias
+	)
+
+	trie := NewTrie()
+	trie.Insert("AEAUH", Term{Text: "AEAUH", Rank: rankCode}, Term{Text: "Abu Dhabi", Rank: rankName})
+	trie.Insert("AEDXB", Term{Text: "AEDXB", Rank: rankCode}, Term{Text: "Dubai", Rank: rankName})
+	trie.Insert("CNFOC", Term{Text: "CNFOC", Rank: rankCode}, Term{Text: "Fuzhou", Rank: rankName},
+		Term{Text: "Foochow", Rank: rankAlias})
+	trie.Insert("JPABU", Term{Text: "JPABU", Rank: rankCode}, Term{Text: "Aburatsu", Rank: rankName})
+	trie.Insert("XXABU", Term{Text: "XXABU", Rank: rankCode}, Term{Text: "Abu", Rank: rankName})
+	require.Equal(t, 5, trie.Len())
+
+	testCases := []struct {
+		prefix   string
+		limit    int
+		expected []Completion
+	}{
+		{
+			prefix: "abu",
+			limit:  10,
+			expected: []Completion{
+				{ID: "XXABU", Text: "Abu", Rank: rankName, Exact: true},
+				{ID: "JPABU", Text: "Aburatsu", Rank: rankName},
+				{ID: "AEAUH", Text: "Abu Dhabi", Rank: rankName},
+			},
+		},
+		{
+			prefix: "ae",
+			limit:  10,
+			expected: []Completion{
+				{ID: "AEAUH", Text: "AEAUH", Rank: rankCode},
+				{ID: "AEDXB", Text: "AEDXB", Rank: rankCode},
+			},
+		},
+		{
+			prefix:   "Abu Dh",
+			limit:    10,
+			expected: []Completion{{ID: "AEAUH", Text: "Abu Dhabi", Rank: rankName}},
+		},
+		{
+			prefix:   "dhab",
+			limit:    10,
+			expected: []Completion{{ID: "AEAUH", Text: "Abu Dhabi", Rank: rankName}},
+		},
+		{
+			prefix:   "foo",
+			limit:    10,
+			expected: []Completion{{ID: "CNFOC", Text: "Foochow", Rank: rankAlias}},
+		},
+		{
+			prefix:   "abu",
+			limit:    1,
+			expected: []Completion{{ID: "XXABU", Text: "Abu", Rank: rankName, Exact: true}},
+		},
+		{prefix: "zz", limit: 10, expected: []Completion{}},
+		{prefix: " ", limit: 10, expected: []Completion{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.prefix, func(t *testing.T) {
+			assert.Equal(t, tc.expected, trie.Complete(tc.prefix, tc.limit))
+		})
+	}
+
+	// the code must rank above the alias of another document
+	trie.Insert("FOXXX", Term{Text: "FOXXX", Rank: rankCode})
+	completions := trie.Complete("fo", 10)
+	require.Len(t, completions, 2)
+	assert.Equal(t, "FOXXX", completions[0].ID)
+	assert.Equal(t, "CNFOC", completions[1].ID)
+
+	// removed and replaced documents must not be completed by their old terms
+	trie.Remove("AEAUH")
+	trie.Insert("CNFOC", Term{Text: "Fuzhou", Rank: rankName})
+	assert.Empty(t, trie.Complete("dhabi", 10))
+	assert.Equal(t, []Completion{{ID: "FOXXX", Text: "FOXXX", Rank: rankCode}}, trie.Complete("fo", 10))
+	assert.Equal(t, 5, trie.Len())
+
+	for _, id := range []string{"AEDXB", "CNFOC", "JPABU", "XXABU", "FOXXX"} {
+		trie.Remove(id)
+	}
+	assert.Empty(t, trie.root.children, "empty nodes must be pruned")
+}
+
+func BenchmarkTrie_Complete(b *testing.B) {
+	trie := NewTrie()
+	for _, name := range []string{"Abu Dhabi", "Aburatsu", "Ajman", "Antwerp", "Auckland", "Amsterdam", "Aarhus"} {
+		for i := 0; i < 200; i++ {
+			trie.Insert(name+string(rune('a'+i%26))+string(rune('a'+i/26)), Term{Text: name})
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		trie.Complete("a", 10)
+	}
+}